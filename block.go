@@ -1,6 +1,12 @@
 package main
 
 import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/KasumiMercury/alchemark/inline"
 	"github.com/KasumiMercury/alchemark/token"
 )
 
@@ -73,32 +79,35 @@ func HeadingDetector(input []rune) (token.BlockToken, bool) {
 	return token.NewHeadingBlock(string(content), level), true
 }
 
+// CodeBlockDetector detects an opening or closing fence of at least three backticks or tildes
 func CodeBlockDetector(input []rune) (token.BlockToken, bool) {
 	if len(input) < 3 {
 		return nil, false
 	}
 
 	fenceChar := input[0]
+	if fenceChar != '`' && fenceChar != '~' {
+		return nil, false
+	}
 
-	if input[1] == fenceChar && input[2] == fenceChar {
-		// TODO: infoStringのスペース取り扱い
-		infoString := ""
+	length := 0
+	for length < len(input) && input[length] == fenceChar {
+		length++
+	}
 
-		if len(input) > 3 {
-			for i, char := range input[3:] {
-				if char == fenceChar {
-					continue
-				}
+	if length < 3 {
+		return nil, false
+	}
 
-				infoString += string(input[i+3:])
-				break
-			}
-		}
+	// the info string is split into its parts by CodeBlock.Info
+	infoString := strings.Trim(string(input[length:]), " \t")
 
-		return token.NewCodeBlockFence(input[0], infoString), true
+	// the info string of a backtick fence cannot contain a backtick, otherwise the line would be an inline code span
+	if fenceChar == '`' && strings.ContainsRune(infoString, '`') {
+		return nil, false
 	}
 
-	return nil, false
+	return token.NewCodeBlockFence(fenceChar, length, infoString), true
 }
 
 func HorizontalDetector(input []rune) (token.BlockToken, bool) {
@@ -111,7 +120,7 @@ func HorizontalDetector(input []rune) (token.BlockToken, bool) {
 
 	// first char is already checked
 	for _, char := range input[1:] {
-		if char == ' ' {
+		if char == ' ' || char == '\t' {
 			continue
 		}
 
@@ -130,31 +139,42 @@ func HorizontalDetector(input []rune) (token.BlockToken, bool) {
 }
 
 func BlockQuoteDetector(input []rune) (token.BlockToken, bool) {
-	if len(input) < 2 {
+	if len(input) == 0 || input[0] != '>' {
 		return nil, false
 	}
 
 	level := 0
-	contents := make([]rune, 0, len(input))
-	spaceCount := 0
+	pos := 0
 
-	for _, char := range input {
-		if char == '>' {
-			level++
-		} else if char == ' ' {
-			spaceCount++
-			continue
-		} else {
-			contents = input[level+spaceCount:]
+	for pos < len(input) && input[pos] == '>' {
+		level++
+		pos++
+
+		// the space after `>` belongs to the marker, and the other spaces indent the content
+		if pos < len(input) && input[pos] == ' ' {
+			pos++
+		}
+
+		// a nested `>` may be indented by up to three spaces
+		next := pos
+		for next < len(input) && input[next] == ' ' {
+			next++
+		}
+		if next < len(input) && input[next] == '>' && next-pos < 4 {
+			pos = next
 		}
 	}
 
-	contentBlock := DetectBlockType(string(contents))
+	contentBlock := DetectBlockType(string(input[pos:]))
 
 	return token.NewBlockQuote(level, contentBlock), true
 }
 
 func ListItemDetector(input []rune) (token.BlockToken, bool) {
+	if len(input) < 2 {
+		return nil, false
+	}
+
 	if input[0] != '-' && input[0] != '+' && input[0] != '*' {
 		return nil, false
 	}
@@ -163,19 +183,81 @@ func ListItemDetector(input []rune) (token.BlockToken, bool) {
 		return nil, false
 	}
 
-	// skip space
-	pos := 2
-	for _, char := range input[2:] {
-		if char == ' ' {
-			pos++
-		} else {
-			break
-		}
+	content, spaces := listItemContent(input[1:])
+
+	if checked, content, ok := taskListItemMarker(content); ok {
+		return token.NewListItem(input[0], 0, DetectBlockType(string(content))).WithWidth(1 + spaces).WithTask(checked), true
 	}
 
-	contentBlock := DetectBlockType(string(input[pos:]))
+	contentBlock := DetectBlockType(string(content))
 
-	return token.NewListItem(input[0], 0, contentBlock), true
+	return token.NewListItem(input[0], 0, contentBlock).WithWidth(1 + spaces), true
+}
+
+func OrderedListItemDetector(input []rune) (token.BlockToken, bool) {
+	pos := 0
+	for pos < len(input) && input[pos] >= '0' && input[pos] <= '9' {
+		pos++
+	}
+
+	// the number is limited to nine digits to avoid integer overflows in browsers
+	if pos == 0 || pos > 9 || pos >= len(input) {
+		return nil, false
+	}
+
+	delimiter := input[pos]
+	if delimiter != '.' && delimiter != ')' {
+		return nil, false
+	}
+	pos++
+
+	if pos < len(input) && input[pos] != ' ' && input[pos] != '\t' {
+		return nil, false
+	}
+
+	start := 0
+	for _, digit := range input[:pos-1] {
+		start = start*10 + int(digit-'0')
+	}
+
+	content, spaces := listItemContent(input[pos:])
+
+	if checked, content, ok := taskListItemMarker(content); ok {
+		return token.NewOrderedListItem(start, delimiter, 0, DetectBlockType(string(content))).WithWidth(pos + spaces).WithTask(checked), true
+	}
+
+	contentBlock := DetectBlockType(string(content))
+
+	return token.NewOrderedListItem(start, delimiter, 0, contentBlock).WithWidth(pos + spaces), true
+}
+
+// taskListItemMarker detects the `[ ]` or `[x]` marker at the start of a list item's content,
+// returning whether it is checked and the content following the marker
+func taskListItemMarker(content []rune) (bool, []rune, bool) {
+	if len(content) < 4 || content[0] != '[' || content[2] != ']' {
+		return false, nil, false
+	}
+
+	if content[3] != ' ' && content[3] != '\t' {
+		return false, nil, false
+	}
+
+	var checked bool
+	switch content[1] {
+	case ' ':
+		checked = false
+	case 'x', 'X':
+		checked = true
+	default:
+		return false, nil, false
+	}
+
+	pos := 4
+	for pos < len(content) && (content[pos] == ' ' || content[pos] == '\t') {
+		pos++
+	}
+
+	return checked, content[pos:], true
 }
 
 func HyphenDetector(input []rune) (token.BlockToken, bool) {
@@ -219,6 +301,63 @@ func AsteriskDetector(input []rune) (token.BlockToken, bool) {
 	return token.NewParagraphBlock(string(input), 0), true
 }
 
+func LinkReferenceDefinitionDetector(input []rune) (token.BlockToken, bool) {
+	if len(input) == 0 || input[0] != '[' {
+		return nil, false
+	}
+
+	label, reference, ok := inline.ParseLinkReferenceDefinition(string(input))
+	if !ok {
+		return nil, false
+	}
+
+	return token.NewLinkReferenceDefinition(label, reference.Destination(), reference.Title(), input), true
+}
+
+// FootnoteDefinitionDetector detects a footnote definition such as `[^1]: text`.
+// The label may not contain whitespace or brackets, and the text after the colon becomes the content block.
+func FootnoteDefinitionDetector(input []rune) (token.BlockToken, bool) {
+	label, length, ok := footnoteDefinitionMarker(input)
+	if !ok {
+		return nil, false
+	}
+
+	var contentBlock token.BlockToken
+	if content := strings.TrimLeft(string(input[length:]), " \t"); content != "" {
+		contentBlock = DetectBlockType(content)
+	}
+
+	return token.NewFootnoteDefinition(label, contentBlock), true
+}
+
+// footnoteDefinitionMarker returns the label of the `[^label]:` marker at the start of input and the length of the marker
+func footnoteDefinitionMarker(input []rune) (string, int, bool) {
+	if len(input) < 5 || input[0] != '[' || input[1] != '^' {
+		return "", 0, false
+	}
+
+	end := 2
+	for end < len(input) && input[end] != ']' {
+		if input[end] == '[' || unicode.IsSpace(input[end]) {
+			return "", 0, false
+		}
+		if input[end] == '\\' {
+			end++
+		}
+		end++
+	}
+
+	if end == 2 || end+1 >= len(input) || input[end+1] != ':' {
+		return "", 0, false
+	}
+
+	return string(input[2:end]), end + 2, true
+}
+
+// tabStop is the width of the tab stops to which a tab advances the column
+const tabStop = 4
+
+// IndentInfo is the indentation of a line measured in columns, with Depth counting each four columns
 type IndentInfo struct {
 	Depth       int
 	SeekPos     int
@@ -226,19 +365,20 @@ type IndentInfo struct {
 }
 
 func countIndent(input []rune) IndentInfo {
-	spaceCount := 0
+	column := 0
 	pos := 0
 
 	for _, char := range input {
 
 		if char == '\t' {
-			spaceCount += 4
+			// a tab advances to the next tab stop rather than by a fixed width
+			column += tabStop - column%tabStop
 			pos++
 			continue
 		}
 
 		if char == ' ' {
-			spaceCount++
+			column++
 			pos++
 			continue
 		}
@@ -247,16 +387,301 @@ func countIndent(input []rune) IndentInfo {
 	}
 
 	return IndentInfo{
-		Depth:       spaceCount / 4,
+		Depth:       column / tabStop,
 		SeekPos:     pos,
-		RemainSpace: spaceCount % 4,
+		RemainSpace: column % tabStop,
+	}
+}
+
+// expandPrefixTabs replaces the tabs in the indentation and between the quote and list markers at the start of the line
+// with the spaces up to the next tab stop, so that the detectors only see spaces while the columns are kept.
+// Tabs after the markers, such as those in code, are left as they are.
+func expandPrefixTabs(input []rune) []rune {
+	if !slices.Contains(input, '\t') {
+		return input
+	}
+
+	expanded := make([]rune, 0, len(input)+tabStop)
+	pos := 0
+
+prefix:
+	for pos < len(input) {
+		char := input[pos]
+
+		switch {
+		case char == '\t':
+			expanded = append(expanded, ' ')
+			for len(expanded)%tabStop != 0 {
+				expanded = append(expanded, ' ')
+			}
+			pos++
+			continue
+		case char == ' ' || char == '>':
+			expanded = append(expanded, char)
+			pos++
+			continue
+		}
+
+		marker := listMarkerLength(input[pos:])
+		if marker == 0 {
+			break prefix
+		}
+
+		expanded = append(expanded, input[pos:pos+marker]...)
+		pos += marker
 	}
+
+	return append(expanded, input[pos:]...)
+}
+
+// listMarkerLength returns the length of the bullet or ordered list marker followed by whitespace at the start of input, or 0
+func listMarkerLength(input []rune) int {
+	end := 0
+	if len(input) > 0 && strings.ContainsRune("-+*", input[0]) {
+		end = 1
+	} else {
+		for end < len(input) && end < 9 && '0' <= input[end] && input[end] <= '9' {
+			end++
+		}
+		if end == 0 || end >= len(input) || (input[end] != '.' && input[end] != ')') {
+			return 0
+		}
+		end++
+	}
+
+	if end >= len(input) || (input[end] != ' ' && input[end] != '\t') {
+		return 0
+	}
+
+	return end
+}
+
+// listItemContent returns the content following the spaces after a list marker and the number of the spaces belonging to the marker.
+// When five or more spaces or only spaces follow the marker, one space belongs to the marker and the content is indented code.
+func listItemContent(input []rune) ([]rune, int) {
+	pos := 0
+	for pos < len(input) && input[pos] == ' ' {
+		pos++
+	}
+
+	if pos == 0 {
+		return input, 1
+	}
+
+	if pos >= 5 || pos == len(input) {
+		return input[1:], 1
+	}
+
+	return input[pos:], pos
+}
+
+// newIndentedBlock returns the line of the indentation as an IndentedBlock, keeping the columns beyond the depth as spaces
+func newIndentedBlock(input []rune, indentInfo IndentInfo) *token.IndentedBlock {
+	self := append([]rune(strings.Repeat(" ", indentInfo.RemainSpace)), input[indentInfo.SeekPos:]...)
+
+	return token.NewIndentedBlock(indentInfo.Depth, self)
+}
+
+// indentedLine returns the whole line as an IndentedBlock, which keeps its indentation for the list item it belongs to
+func indentedLine(line string) *token.IndentedBlock {
+	input := expandPrefixTabs([]rune(line))
+
+	return newIndentedBlock(input, countIndent(input))
+}
+
+// quoteLineContent returns the line without its first quote marker and the space following it.
+// It reports false for a line without a quote marker, which can only be a lazy continuation line of the quote.
+func quoteLineContent(line string) (string, bool) {
+	input := expandPrefixTabs([]rune(line))
+
+	indentInfo := countIndent(input)
+	if indentInfo.Depth > 0 || indentInfo.SeekPos >= len(input) || input[indentInfo.SeekPos] != '>' {
+		return "", false
+	}
+
+	pos := indentInfo.SeekPos + 1
+	if pos < len(input) && input[pos] == ' ' {
+		pos++
+	}
+
+	return string(input[pos:]), true
+}
+
+// listItemLineContent returns the first line of the list item without its marker, the spaces belonging to the marker and the task marker
+func listItemLineContent(line string, item token.ListItem) string {
+	input := expandPrefixTabs([]rune(line))
+	content := input[min(item.ContentColumn(), len(input)):]
+
+	if item.Task() {
+		if _, rest, ok := taskListItemMarker(content); ok {
+			content = rest
+		}
+	}
+
+	return string(content)
+}
+
+// footnoteLineContent returns the first line of the footnote definition without its marker
+func footnoteLineContent(line string) string {
+	input := expandPrefixTabs([]rune(line))
+	input = input[countIndent(input).SeekPos:]
+
+	if _, length, ok := footnoteDefinitionMarker(input); ok {
+		return strings.TrimLeft(string(input[length:]), " \t")
+	}
+
+	return string(input)
+}
+
+// indentedContent returns the line without the given columns of its indentation.
+// It reports false for a line indented by fewer columns.
+func indentedContent(line string, columns int) (string, bool) {
+	input := expandPrefixTabs([]rune(line))
+
+	// the tabs of the indentation are expanded, so each column of it is one space
+	if countIndent(input).SeekPos < columns {
+		return "", false
+	}
+
+	return string(input[columns:]), true
+}
+
+// withListColumn places a detected list item at the column of its marker
+func withListColumn(tk token.BlockToken, column int) token.BlockToken {
+	if item, ok := tk.(token.ListItem); ok {
+		return item.WithColumn(column)
+	}
+
+	return tk
+}
+
+// TableDelimiterRowDetector detects a row such as `| --- | :-: |`, whose cells give the alignments of the table columns
+func TableDelimiterRowDetector(input []rune) (token.BlockToken, bool) {
+	if !strings.ContainsRune(string(input), '|') {
+		return nil, false
+	}
+
+	cells := token.SplitTableRow(string(input))
+	alignments := make([]token.Alignment, 0, len(cells))
+
+	for _, cell := range cells {
+		left := strings.HasPrefix(cell, ":")
+		right := strings.HasSuffix(cell, ":")
+
+		dashes := strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+
+		switch {
+		case left && right:
+			alignments = append(alignments, token.AlignCenter)
+		case left:
+			alignments = append(alignments, token.AlignLeft)
+		case right:
+			alignments = append(alignments, token.AlignRight)
+		default:
+			alignments = append(alignments, token.AlignNone)
+		}
+	}
+
+	return token.NewTableDelimiterRow(alignments, input), true
+}
+
+// htmlBlockEndMarkers are the strings closing the HTML blocks of conditions 1 to 5
+var htmlBlockEndMarkers = map[int][]string{
+	1: {"</pre>", "</script>", "</style>", "</textarea>"},
+	2: {"-->"},
+	3: {"?>"},
+	4: {">"},
+	5: {"]]>"},
+}
+
+var htmlRawTagNames = []string{"pre", "script", "style", "textarea"}
+
+var htmlBlockTagNames = map[string]bool{
+	"address": true, "article": true, "aside": true, "base": true, "basefont": true, "blockquote": true, "body": true,
+	"caption": true, "center": true, "col": true, "colgroup": true, "dd": true, "details": true, "dialog": true,
+	"dir": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "frame": true, "frameset": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "header": true, "hr": true, "html": true, "iframe": true, "legend": true,
+	"li": true, "link": true, "main": true, "menu": true, "menuitem": true, "nav": true, "noframes": true, "ol": true,
+	"optgroup": true, "option": true, "p": true, "param": true, "search": true, "section": true, "summary": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true, "title": true, "tr": true,
+	"track": true, "ul": true,
+}
+
+var (
+	htmlTagNameRegexp = regexp.MustCompile(`^</?([A-Za-z][A-Za-z0-9-]*)`)
+	// htmlCompleteTagRegexp matches a line holding only a complete open or closing tag
+	htmlCompleteTagRegexp = regexp.MustCompile(`^(?:<[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:-]*(?:\s*=\s*(?:[^"'=<>\x60\x00-\x20]+|'[^']*'|"[^"]*"))?)*\s*/?>|</[A-Za-z][A-Za-z0-9-]*\s*>)\s*$`)
+)
+
+// HTMLBlockDetector detects the start of an HTML block, following the seven start conditions of CommonMark
+func HTMLBlockDetector(input []rune) (token.BlockToken, bool) {
+	if len(input) == 0 || input[0] != '<' {
+		return nil, false
+	}
+
+	line := string(input)
+	lower := strings.ToLower(line)
+
+	for _, name := range htmlRawTagNames {
+		if rest, ok := strings.CutPrefix(lower, "<"+name); ok && (rest == "" || strings.ContainsRune(" \t>", rune(rest[0]))) {
+			return token.NewHTMLBlock(1, []string{line}), true
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(line, "<!--"):
+		return token.NewHTMLBlock(2, []string{line}), true
+	case strings.HasPrefix(line, "<?"):
+		return token.NewHTMLBlock(3, []string{line}), true
+	case strings.HasPrefix(line, "<![CDATA["):
+		return token.NewHTMLBlock(5, []string{line}), true
+	case len(line) > 2 && line[1] == '!' && isASCIILetter(line[2]):
+		return token.NewHTMLBlock(4, []string{line}), true
+	}
+
+	if match := htmlTagNameRegexp.FindStringSubmatch(line); match != nil && htmlBlockTagNames[strings.ToLower(match[1])] {
+		rest := line[len(match[0]):]
+		if rest == "" || strings.ContainsRune(" \t>", rune(rest[0])) || strings.HasPrefix(rest, "/>") {
+			return token.NewHTMLBlock(6, []string{line}), true
+		}
+	}
+
+	if htmlCompleteTagRegexp.MatchString(line) {
+		return token.NewHTMLBlock(7, []string{line}), true
+	}
+
+	return nil, false
+}
+
+// htmlBlockEnds reports whether the line closes an HTML block of the condition 1 to 5.
+// Blocks of the conditions 6 and 7 end at a blank line instead.
+func htmlBlockEnds(condition int, line string) bool {
+	if condition == 1 {
+		line = strings.ToLower(line)
+	}
+
+	for _, marker := range htmlBlockEndMarkers[condition] {
+		if strings.Contains(line, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
 }
 
 func DetectBlockType(line string) token.BlockToken {
-	input := []rune(line)
+	input := expandPrefixTabs([]rune(line))
 
 	indentInfo := countIndent(input)
+	line = string(input)
 	input = input[indentInfo.SeekPos:]
 
 	if len(input) == 0 {
@@ -264,27 +689,25 @@ func DetectBlockType(line string) token.BlockToken {
 	}
 
 	firstChar := input[0]
+	// column is the indentation including the partial indentation of one to three spaces
+	column := indentInfo.Depth*tabStop + indentInfo.RemainSpace
 
 	if indentInfo.Depth > 0 {
-		// TODO: handle remaining space
-
+		// a list item keeps its column, since it may be nested in an item whose content starts there
 		switch firstChar {
-		case '-':
-			// TODO: If there is remaining space, can the line be a list item?
-			if tk, ok := ListItemDetector(input); ok {
-				return tk.(token.ListItem).Indent(indentInfo.Depth)
+		case '-', '*', '+':
+			if _, ok := HorizontalDetector(input); !ok {
+				if tk, ok := ListItemDetector(input); ok {
+					return withListColumn(tk, column)
+				}
 			}
-		default:
-			// Add remaining space to input
-			remainingSpaceRunes := make([]rune, indentInfo.RemainSpace)
-			for i := 0; i < indentInfo.RemainSpace; i++ {
-				remainingSpaceRunes[i] = ' '
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			if tk, ok := OrderedListItemDetector(input); ok {
+				return withListColumn(tk, column)
 			}
-
-			selfRunes := append(remainingSpaceRunes, input...)
-
-			return token.NewIndentedBlock(indentInfo.Depth, selfRunes)
 		}
+
+		return newIndentedBlock([]rune(line), indentInfo)
 	}
 
 	switch firstChar {
@@ -292,21 +715,27 @@ func DetectBlockType(line string) token.BlockToken {
 		if tk, ok := HeadingDetector(input); ok {
 			return tk
 		}
-	case '`':
-		if tk, ok := CodeBlockDetector(input); ok {
-			return tk
-		}
-	case '~':
+	case '`', '~':
 		if tk, ok := CodeBlockDetector(input); ok {
-			return tk
+			return tk.(*token.CodeBlockFence).WithIndent(indentInfo.RemainSpace)
 		}
 	case '-':
+		// `- | -` is a list item rather than a delimiter row
+		if len(input) < 2 || input[1] != ' ' {
+			if tk, ok := TableDelimiterRowDetector(input); ok {
+				return tk
+			}
+		}
 		if tk, ok := HyphenDetector(input); ok {
+			return withListColumn(tk, column)
+		}
+	case '|', ':':
+		if tk, ok := TableDelimiterRowDetector(input); ok {
 			return tk
 		}
 	case '*':
 		if tk, ok := AsteriskDetector(input); ok {
-			return tk
+			return withListColumn(tk, column)
 		}
 	case '>':
 		if tk, ok := BlockQuoteDetector(input); ok {
@@ -314,7 +743,7 @@ func DetectBlockType(line string) token.BlockToken {
 		}
 	case '+':
 		if tk, ok := ListItemDetector(input); ok {
-			return tk
+			return withListColumn(tk, column)
 		}
 	case '_':
 		if tk, ok := HorizontalDetector(input); ok {
@@ -322,6 +751,21 @@ func DetectBlockType(line string) token.BlockToken {
 		}
 	case '=':
 		return token.NewEqual(input)
+	case '<':
+		if tk, ok := HTMLBlockDetector(input); ok {
+			return tk
+		}
+	case '[':
+		if tk, ok := FootnoteDefinitionDetector(input); ok {
+			return tk
+		}
+		if tk, ok := LinkReferenceDefinitionDetector(input); ok {
+			return tk
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if tk, ok := OrderedListItemDetector(input); ok {
+			return withListColumn(tk, column)
+		}
 	default:
 		return token.NewParagraphBlock(line, 0)
 	}
@@ -93,15 +93,24 @@ func TestCountIndent(t *testing.T) {
 			want: IndentInfo{
 				1,
 				4,
-				3,
+				0,
 			},
 		},
 		{
 			name:  "indent sandwiched by spaces",
 			input: "  \t  mix 4 spaces and 1 tab",
 			want: IndentInfo{
-				2,
+				1,
 				5,
+				2,
+			},
+		},
+		{
+			name:  "Tab advances to the next tab stop",
+			input: " \t \ttab stops",
+			want: IndentInfo{
+				2,
+				4,
 				0,
 			},
 		},
@@ -361,7 +370,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "```",
 			},
 			want: want{
-				token.NewCodeBlockFence('`', ""),
+				token.NewCodeBlockFence('`', 3, ""),
 				true,
 			},
 		},
@@ -371,7 +380,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "```go",
 			},
 			want: want{
-				token.NewCodeBlockFence('`', "go"),
+				token.NewCodeBlockFence('`', 3, "go"),
 				true,
 			},
 		},
@@ -381,7 +390,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "~~~",
 			},
 			want: want{
-				token.NewCodeBlockFence('~', ""),
+				token.NewCodeBlockFence('~', 3, ""),
 				true,
 			},
 		},
@@ -391,7 +400,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "~~~ruby",
 			},
 			want: want{
-				token.NewCodeBlockFence('~', "ruby"),
+				token.NewCodeBlockFence('~', 3, "ruby"),
 				true,
 			},
 		},
@@ -401,7 +410,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "````",
 			},
 			want: want{
-				token.NewCodeBlockFence('`', ""),
+				token.NewCodeBlockFence('`', 4, ""),
 				true,
 			},
 		},
@@ -411,7 +420,7 @@ func TestCodeBlockDetector(t *testing.T) {
 				input: "````python",
 			},
 			want: want{
-				token.NewCodeBlockFence('`', "python"),
+				token.NewCodeBlockFence('`', 4, "python"),
 				true,
 			},
 		},
@@ -455,6 +464,46 @@ func TestCodeBlockDetector(t *testing.T) {
 				false,
 			},
 		},
+		{
+			name: "other char will be not CodeBlock",
+			args: args{
+				input: "aaa",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "infoString will be trimmed",
+			args: args{
+				input: "``` go ",
+			},
+			want: want{
+				token.NewCodeBlockFence('`', 3, "go"),
+				true,
+			},
+		},
+		{
+			name: "backtick in infoString of ``` will be not CodeBlock",
+			args: args{
+				input: "``` a`b",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "backtick in infoString of ~~~ will be allowed",
+			args: args{
+				input: "~~~ a`b",
+			},
+			want: want{
+				token.NewCodeBlockFence('~', 3, "a`b"),
+				true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -678,6 +727,19 @@ func TestBlockQuoteDetector(t *testing.T) {
 				true,
 			},
 		},
+		{
+			name: "Blockquote content with spaces",
+			args: args{
+				input: "> Blockquote with spaces",
+			},
+			want: want{
+				token.NewBlockQuote(
+					1,
+					token.NewParagraphBlock("Blockquote with spaces", 0),
+				),
+				true,
+			},
+		},
 		{
 			name: "Spaces after > can be omitted",
 			args: args{
@@ -691,6 +753,32 @@ func TestBlockQuoteDetector(t *testing.T) {
 				true,
 			},
 		},
+		{
+			name: "Only one space after > belongs to the marker",
+			args: args{
+				input: ">     code",
+			},
+			want: want{
+				token.NewBlockQuote(
+					1,
+					token.NewIndentedBlock(1, []rune("code")),
+				),
+				true,
+			},
+		},
+		{
+			name: "Nested > indented by spaces",
+			args: args{
+				input: ">  > nested",
+			},
+			want: want{
+				token.NewBlockQuote(
+					2,
+					token.NewParagraphBlock("nested", 0),
+				),
+				true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -735,6 +823,46 @@ func TestListItemDetector(t *testing.T) {
 				true,
 			},
 		},
+		{
+			name: "Unchecked task list item",
+			args: args{
+				input: "- [ ] todo",
+			},
+			want: want{
+				token.NewListItem('-', 0, token.NewParagraphBlock("todo", 0)).WithTask(false),
+				true,
+			},
+		},
+		{
+			name: "Checked task list item",
+			args: args{
+				input: "* [X] done",
+			},
+			want: want{
+				token.NewListItem('*', 0, token.NewParagraphBlock("done", 0)).WithTask(true),
+				true,
+			},
+		},
+		{
+			name: "Task list marker without space",
+			args: args{
+				input: "- [x]done",
+			},
+			want: want{
+				token.NewListItem('-', 0, token.NewParagraphBlock("[x]done", 0)),
+				true,
+			},
+		},
+		{
+			name: "Invalid task list marker",
+			args: args{
+				input: "- [o] other",
+			},
+			want: want{
+				token.NewListItem('-', 0, token.NewParagraphBlock("[o] other", 0)),
+				true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -748,6 +876,116 @@ func TestListItemDetector(t *testing.T) {
 	}
 }
 
+func TestOrderedListItemDetector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+
+	type want struct {
+		token  token.BlockToken
+		detect bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Ordered list item with .",
+			args: args{
+				input: "1. step",
+			},
+			want: want{
+				token.NewOrderedListItem(1, '.', 0, token.NewParagraphBlock("step", 0)),
+				true,
+			},
+		},
+		{
+			name: "Ordered task list item",
+			args: args{
+				input: "2) [x] step",
+			},
+			want: want{
+				token.NewOrderedListItem(2, ')', 0, token.NewParagraphBlock("step", 0)).WithTask(true),
+				true,
+			},
+		},
+		{
+			name: "Ordered list item with )",
+			args: args{
+				input: "3) step",
+			},
+			want: want{
+				token.NewOrderedListItem(3, ')', 0, token.NewParagraphBlock("step", 0)),
+				true,
+			},
+		},
+		{
+			name: "Leading zeros are dropped from the start number",
+			args: args{
+				input: "003. step",
+			},
+			want: want{
+				token.NewOrderedListItem(3, '.', 0, token.NewParagraphBlock("step", 0)).WithWidth(5),
+				true,
+			},
+		},
+		{
+			name: "Empty ordered list item",
+			args: args{
+				input: "2.",
+			},
+			want: want{
+				token.NewOrderedListItem(2, '.', 0, token.NewBlank()),
+				true,
+			},
+		},
+		{
+			name: "More than nine digits will be not list item",
+			args: args{
+				input: "1234567890. step",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Missing space after delimiter will be not list item",
+			args: args{
+				input: "2.step",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Other delimiter will be not list item",
+			args: args{
+				input: "2: step",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, detect := OrderedListItemDetector([]rune(tt.args.input)); !reflect.DeepEqual(got, tt.want.token) || detect != tt.want.detect {
+				t.Errorf("OrderedListItemDetector() = {%v}, %v / want {%v}, %v", got, detect, tt.want.token, tt.want.detect)
+			}
+		})
+	}
+}
+
 func TestHyphenDetector(t *testing.T) {
 	t.Parallel()
 
@@ -896,6 +1134,309 @@ func TestAsteriskDetector(t *testing.T) {
 	}
 }
 
+func TestLinkReferenceDefinitionDetector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+
+	type want struct {
+		token  token.BlockToken
+		detect bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Link reference definition",
+			args: args{
+				input: `[foo]: /url "title"`,
+			},
+			want: want{
+				token.NewLinkReferenceDefinition("foo", "/url", "title", []rune(`[foo]: /url "title"`)),
+				true,
+			},
+		},
+		{
+			name: "Link without definition",
+			args: args{
+				input: "[foo](/url)",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, detect := LinkReferenceDefinitionDetector([]rune(tt.args.input)); !reflect.DeepEqual(got, tt.want.token) || detect != tt.want.detect {
+				t.Errorf("LinkReferenceDefinitionDetector() = {%v}, %v / want {%v}, %v", got, detect, tt.want.token, tt.want.detect)
+			}
+		})
+	}
+}
+
+func TestFootnoteDefinitionDetector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+
+	type want struct {
+		token  token.BlockToken
+		detect bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Footnote definition",
+			args: args{
+				input: "[^1]: Note text",
+			},
+			want: want{
+				token.NewFootnoteDefinition("1", token.NewParagraphBlock("Note text", 0)),
+				true,
+			},
+		},
+		{
+			name: "Footnote definition without content",
+			args: args{
+				input: "[^note]:",
+			},
+			want: want{
+				token.NewFootnoteDefinition("note", nil),
+				true,
+			},
+		},
+		{
+			name: "Footnote definition with heading content",
+			args: args{
+				input: "[^a]: # Heading",
+			},
+			want: want{
+				token.NewFootnoteDefinition("a", token.NewHeadingBlock("Heading", 1)),
+				true,
+			},
+		},
+		{
+			name: "Label with whitespace is not footnote definition",
+			args: args{
+				input: "[^a b]: text",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Empty label is not footnote definition",
+			args: args{
+				input: "[^]: text",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Footnote reference is not footnote definition",
+			args: args{
+				input: "[^1] text",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, detect := FootnoteDefinitionDetector([]rune(tt.args.input)); !reflect.DeepEqual(got, tt.want.token) || detect != tt.want.detect {
+				t.Errorf("FootnoteDefinitionDetector() = {%v}, %v / want {%v}, %v", got, detect, tt.want.token, tt.want.detect)
+			}
+		})
+	}
+}
+
+func TestTableDelimiterRowDetector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+	type want struct {
+		token  token.BlockToken
+		detect bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Delimiter row with alignments",
+			args: args{
+				input: "| --- | :-- | --: | :-: |",
+			},
+			want: want{
+				token.NewTableDelimiterRow([]token.Alignment{token.AlignNone, token.AlignLeft, token.AlignRight, token.AlignCenter}, []rune("| --- | :-- | --: | :-: |")),
+				true,
+			},
+		},
+		{
+			name: "Delimiter row without outer pipes",
+			args: args{
+				input: "---|---",
+			},
+			want: want{
+				token.NewTableDelimiterRow([]token.Alignment{token.AlignNone, token.AlignNone}, []rune("---|---")),
+				true,
+			},
+		},
+		{
+			name: "Delimiter row without pipe",
+			args: args{
+				input: ":---:",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Cell without dash",
+			args: args{
+				input: "| --- | : |",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+		{
+			name: "Cell with text",
+			args: args{
+				input: "| --- | a |",
+			},
+			want: want{
+				nil,
+				false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, detect := TableDelimiterRowDetector([]rune(tt.args.input)); !reflect.DeepEqual(got, tt.want.token) || detect != tt.want.detect {
+				t.Errorf("TableDelimiterRowDetector() = {%v}, %v / want {%v}, %v", got, detect, tt.want.token, tt.want.detect)
+			}
+		})
+	}
+}
+
+func TestHTMLBlockDetector(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+	type want struct {
+		token  token.BlockToken
+		detect bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Script",
+			args: args{input: "<script type=\"text/javascript\">"},
+			want: want{token.NewHTMLBlock(1, []string{"<script type=\"text/javascript\">"}), true},
+		},
+		{
+			name: "Pre in upper case",
+			args: args{input: "<PRE>"},
+			want: want{token.NewHTMLBlock(1, []string{"<PRE>"}), true},
+		},
+		{
+			name: "Comment",
+			args: args{input: "<!-- comment"},
+			want: want{token.NewHTMLBlock(2, []string{"<!-- comment"}), true},
+		},
+		{
+			name: "Processing instruction",
+			args: args{input: "<?xml version=\"1.0\"?>"},
+			want: want{token.NewHTMLBlock(3, []string{"<?xml version=\"1.0\"?>"}), true},
+		},
+		{
+			name: "Declaration",
+			args: args{input: "<!DOCTYPE html>"},
+			want: want{token.NewHTMLBlock(4, []string{"<!DOCTYPE html>"}), true},
+		},
+		{
+			name: "CDATA",
+			args: args{input: "<![CDATA["},
+			want: want{token.NewHTMLBlock(5, []string{"<![CDATA["}), true},
+		},
+		{
+			name: "Block tag",
+			args: args{input: "<div class=\"note\""},
+			want: want{token.NewHTMLBlock(6, []string{"<div class=\"note\""}), true},
+		},
+		{
+			name: "Closing block tag",
+			args: args{input: "</td>"},
+			want: want{token.NewHTMLBlock(6, []string{"</td>"}), true},
+		},
+		{
+			name: "Complete custom tag",
+			args: args{input: "<custom-element data-x='1'>  "},
+			want: want{token.NewHTMLBlock(7, []string{"<custom-element data-x='1'>  "}), true},
+		},
+		{
+			name: "Tag followed by text",
+			args: args{input: "<span>text</span>"},
+			want: want{nil, false},
+		},
+		{
+			name: "Autolink",
+			args: args{input: "<https://example.com>"},
+			want: want{nil, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, detect := HTMLBlockDetector([]rune(tt.args.input)); !reflect.DeepEqual(got, tt.want.token) || detect != tt.want.detect {
+				t.Errorf("HTMLBlockDetector() = {%v}, %v / want {%v}, %v", got, detect, tt.want.token, tt.want.detect)
+			}
+		})
+	}
+}
+
 // TODO: Add test for EqualDetector
 
 func TestDetectBlockTypeSuccess(t *testing.T) {
@@ -935,6 +1476,11 @@ func TestDetectBlockTypeSuccess(t *testing.T) {
 			args: args{input: "    - List item"},
 			want: token.NewListItem('-', 1, token.NewParagraphBlock("List item", 0)),
 		},
+		{
+			name: "Indented ordered list item",
+			args: args{input: "    2) List item"},
+			want: token.NewOrderedListItem(2, ')', 1, token.NewParagraphBlock("List item", 0)),
+		},
 		{
 			name: "IndentedBlock",
 			args: args{input: "    IndentedBlock"},
@@ -945,6 +1491,41 @@ func TestDetectBlockTypeSuccess(t *testing.T) {
 			args: args{input: "      IndentedBlock"},
 			want: token.NewIndentedBlock(1, []rune("  IndentedBlock")),
 		},
+		{
+			name: "Tab after spaces reaches the tab stop",
+			args: args{input: "  \tfoo\tbar"},
+			want: token.NewIndentedBlock(1, []rune("foo\tbar")),
+		},
+		{
+			name: "Tab after > is expanded from its column",
+			args: args{input: ">\t\tfoo"},
+			want: token.NewBlockQuote(1, token.NewIndentedBlock(1, []rune("  foo"))),
+		},
+		{
+			name: "List item content with five or more spaces is indented code",
+			args: args{input: "-\t\tfoo"},
+			want: token.NewListItem('-', 0, token.NewIndentedBlock(1, []rune("  foo"))),
+		},
+		{
+			name: "Thematic break separated by tabs",
+			args: args{input: "*\t*\t*\t"},
+			want: token.NewHorizontal(),
+		},
+		{
+			name: "Fence with partial indentation",
+			args: args{input: "  ```go"},
+			want: token.NewCodeBlockFence('`', 3, "go").WithIndent(2),
+		},
+		{
+			name: "Block quote with partial indentation",
+			args: args{input: "   > quote"},
+			want: token.NewBlockQuote(1, token.NewParagraphBlock("quote", 0)),
+		},
+		{
+			name: "List item with partial indentation",
+			args: args{input: "   * item"},
+			want: token.NewListItem('*', 0, token.NewParagraphBlock("item", 0)).WithColumn(3),
+		},
 		{
 			name: "Paragraph",
 			args: args{input: "Paragraph"},
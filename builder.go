@@ -0,0 +1,530 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// blockBuilder combines the tokens detected per line into block tokens.
+// The last token is held back until the next line is known, since a following line may rewrite it
+// (e.g. a paragraph becoming a setext heading), and every other token is passed to emit as soon as it is closed.
+type blockBuilder struct {
+	references inline.References
+	emit       func(token.BlockToken)
+	// slugs attaches the anchor IDs to the headings as they are emitted
+	slugs *Slugger
+
+	last   token.BlockToken
+	inList bool
+	// listContent is the content column of the last list item
+	listContent int
+
+	// line and offset locate the line which is currently added
+	line   int
+	offset int
+	// previousLine and beforePreviousLine are the spans of the two lines added last
+	previousLine       token.Position
+	beforePreviousLine token.Position
+
+	frontMatter *frontMatter
+	// container is set for the builder of the lines inside a container block, where no front matter is recognized
+	container bool
+	// replaying is set while the lines of an unclosed front matter are added again as ordinary lines
+	replaying bool
+
+	openingCodeBlockFence *token.CodeBlockFence
+	openingCodeBlockStart token.Pos
+	codeBuffer            []string
+
+	// codeBlanks are the blank lines following an indented code block, which belong to it when the code continues
+	codeBlanks []heldLine
+
+	openingHTMLBlock *token.HTMLBlock
+	htmlBlockSpan    token.Position
+	htmlBuffer       []string
+}
+
+// heldLine is a line whose token is held back until the following lines decide where it belongs
+type heldLine struct {
+	line     string
+	token    token.BlockToken
+	position token.Position
+}
+
+// frontMatter holds the lines of a front matter block until its closing fence is found.
+// Nothing is emitted while it is open, so a fence which is never closed holds back the whole input until its end.
+type frontMatter struct {
+	format token.FrontMatterFormat
+	fence  string
+	lines  []string
+	tokens []token.BlockToken
+}
+
+// frontMatterFence reports the format of the front matter opened by the line
+func frontMatterFence(line string) (token.FrontMatterFormat, bool) {
+	switch strings.TrimRight(line, " \t") {
+	case "---":
+		return token.FrontMatterYAML, true
+	case "+++":
+		return token.FrontMatterTOML, true
+	}
+
+	return "", false
+}
+
+func newBlockBuilder(references inline.References, emit func(token.BlockToken)) *blockBuilder {
+	return &blockBuilder{
+		references: references,
+		emit:       emit,
+		slugs:      NewSlugger(),
+		codeBuffer: make([]string, 0),
+	}
+}
+
+func (b *blockBuilder) push(tk token.BlockToken) {
+	if b.last != nil {
+		b.emit(b.slugs.withHeadingID(b.last))
+	}
+	b.last = tk
+
+	switch t := tk.(type) {
+	case token.ListItem:
+		b.inList = true
+		b.listContent = t.ContentColumn()
+	case token.FootnoteDefinition:
+		// the indented continuation lines of a footnote definition are grouped like those of a list item
+		b.inList = true
+		b.listContent = footnoteContentColumn
+	case token.Blank, *token.IndentedBlock:
+	case *token.ParagraphBlock:
+		if t.Depth() == 0 {
+			b.inList = false
+		}
+	default:
+		b.inList = false
+	}
+}
+
+func (b *blockBuilder) lastType() token.BlockType {
+	if b.last == nil {
+		return token.BlankBlockType
+	}
+
+	return b.last.Type()
+}
+
+func (b *blockBuilder) collectReference(tk token.BlockToken) {
+	switch t := tk.(type) {
+	case token.LinkReferenceDefinition:
+		b.references.Add(t.Label(), t.Reference())
+	case token.BlockQuote:
+		b.collectReference(t.ContentBlock())
+	case token.ListItem:
+		b.collectReference(t.ContentBlock())
+	}
+}
+
+// linePosition returns the span of the line which is currently added
+func (b *blockBuilder) linePosition(line string) token.Position {
+	return token.Position{
+		Start: token.Pos{Offset: b.offset, Line: b.line + 1, Column: 1},
+		End:   token.Pos{Offset: b.offset + len(line), Line: b.line + 1, Column: len(line) + 1},
+	}
+}
+
+// newContainerBuilder returns a builder for the lines inside a block quote, list item or footnote definition
+func newContainerBuilder(references inline.References, emit func(token.BlockToken)) *blockBuilder {
+	b := newBlockBuilder(references, emit)
+	b.container = true
+
+	return b
+}
+
+// add consumes one line together with the token detected for it
+func (b *blockBuilder) add(line string, tk token.BlockToken) {
+	b.addAt(line, tk, b.linePosition(line))
+}
+
+// addAt consumes one line located at the position.
+// The lines inside a container block are added without their markers, so their position is given by the source line.
+func (b *blockBuilder) addAt(line string, tk token.BlockToken, position token.Position) {
+	defer func() {
+		b.line++
+		b.offset += len(line) + 1
+		b.beforePreviousLine = b.previousLine
+		b.previousLine = position
+	}()
+
+	if tk == nil {
+		return
+	}
+
+	if b.frontMatter != nil {
+		if strings.TrimRight(line, " \t") == b.frontMatter.fence {
+			content := strings.Join(b.frontMatter.lines[1:], "\n")
+			b.push(token.WithPosition(token.NewFrontMatter(b.frontMatter.format, content), token.Position{Start: token.Pos{Line: 1, Column: 1}, End: position.End}))
+			b.frontMatter = nil
+			return
+		}
+
+		b.frontMatter.lines = append(b.frontMatter.lines, line)
+		b.frontMatter.tokens = append(b.frontMatter.tokens, tk)
+		return
+	}
+
+	// front matter is only recognized at the very start of the document
+	if b.line == 0 && !b.replaying && !b.container {
+		if format, ok := frontMatterFence(line); ok {
+			b.frontMatter = &frontMatter{
+				format: format,
+				fence:  strings.TrimRight(line, " \t"),
+				lines:  []string{line},
+				tokens: []token.BlockToken{tk},
+			}
+			return
+		}
+	}
+
+	if b.openingCodeBlockFence != nil {
+		if fenceToken, ok := tk.(*token.CodeBlockFence); ok && fenceToken.Closes(b.openingCodeBlockFence) {
+			b.closeCodeBlock(position.End)
+			return
+		}
+
+		b.codeBuffer = append(b.codeBuffer, trimFenceIndent(line, b.openingCodeBlockFence.Indent()))
+		return
+	}
+
+	if b.openingHTMLBlock != nil {
+		// the blank line closing a block of the condition 6 or 7 is not part of the block
+		if b.openingHTMLBlock.Condition() >= 6 && tk.Type() == token.BlankBlockType {
+			b.closeHTMLBlock()
+		} else {
+			b.htmlBuffer = append(b.htmlBuffer, line)
+			b.htmlBlockSpan.End = position.End
+			if htmlBlockEnds(b.openingHTMLBlock.Condition(), line) {
+				b.closeHTMLBlock()
+			}
+			return
+		}
+	}
+
+	// a list item indented by four or more columns outside a list is indented code instead
+	if item, ok := tk.(token.ListItem); ok && item.Column() >= 4 && !b.inList {
+		tk = indentedLine(line)
+	}
+
+	// a block indented by one to three columns in a list keeps its indentation, which decides the item it belongs to
+	if b.inList && keepsListIndent(tk, b.paragraphOpen()) && countIndent(expandPrefixTabs([]rune(line))).RemainSpace > 0 {
+		tk = indentedLine(line)
+	}
+
+	if _, ok := b.last.(*token.IndentedCodeBlock); ok && tk.Type() == token.BlankBlockType {
+		b.codeBlanks = append(b.codeBlanks, heldLine{line, tk, position})
+		return
+	}
+
+	if _, ok := tk.(*token.IndentedBlock); !ok || b.inList {
+		b.releaseCodeBlanks()
+	}
+
+	if html, ok := tk.(*token.HTMLBlock); ok {
+		// only the blocks of the conditions 1 to 6 may interrupt a paragraph
+		if html.Condition() == 7 && b.paragraphOpen() {
+			tk = token.NewParagraphBlock(line, 0)
+		} else {
+			b.openingHTMLBlock = html
+			b.htmlBlockSpan = position
+			b.htmlBuffer = []string{line}
+			if htmlBlockEnds(html.Condition(), line) {
+				b.closeHTMLBlock()
+			}
+			return
+		}
+	}
+
+	if fenceToken, ok := tk.(*token.CodeBlockFence); ok {
+		b.openingCodeBlockFence = fenceToken
+		b.openingCodeBlockStart = position.Start
+		return
+	}
+
+	// indented lines following a list item are kept as IndentedBlock and resolved when the document tree is built
+	if indented, ok := tk.(*token.IndentedBlock); ok {
+		if b.inList {
+			b.push(token.WithPosition(indented, position))
+			return
+		}
+
+		// an indented code block cannot interrupt a paragraph, so the line continues it
+		aboveType := b.lastType()
+		if b.paragraphOpen() {
+			aboveType = token.ParagraphBlockType
+		}
+
+		tk = indented.ConvertBlockToIndentedCodeBlock(aboveType)
+
+		if code, ok := b.last.(*token.IndentedCodeBlock); ok && tk.Type() == token.IndentedCodeBlockType {
+			for _, blank := range b.codeBlanks {
+				code = code.AppendLine(trimCodeIndent(blank.line))
+			}
+			b.codeBlanks = nil
+
+			b.last = token.WithPosition(code.AppendLine(trimCodeIndent(line)), token.Position{Start: code.Position().Start, End: position.End})
+			return
+		}
+	}
+
+	if table, ok := b.last.(*token.Table); ok && continuesTable(tk) {
+		b.last = token.WithPosition(table.AddRow(line), token.Position{Start: table.Position().Start, End: position.End})
+		return
+	}
+
+	if row, ok := tk.(token.TableDelimiterRow); ok {
+		if rest, table, ok := row.ConvertBlockToTable(b.last); ok {
+			// the header is the last line of the paragraph above
+			header := b.previousLine
+			if rest != nil {
+				b.last = token.WithPosition(rest, token.Position{Start: b.last.Position().Start, End: b.beforePreviousLine.End})
+			} else {
+				b.last = nil
+			}
+
+			b.push(token.WithPosition(table, token.Position{Start: header.Start, End: position.End}))
+			return
+		}
+
+		tk = row.ConvertBlockToParagraph()
+	}
+
+	if sht, ok := tk.(token.SetextHeadingToken); ok {
+		if hyphen, ok := sht.(token.HyphenToken); ok && b.last == nil && hyphen.CanHorizontal() {
+			// with no paragraph above, `---` is a thematic break, which is also how an unclosed front matter fence is read
+			tk = token.NewHorizontal()
+		} else if b.last == nil {
+			tk = sht.ConvertBlockToParagraph()
+		} else if target, self := sht.ConvertBlockToSetextHeading(b.last); self.Type() == token.SetextBlockType {
+			b.last = token.WithPosition(target, token.Position{Start: b.last.Position().Start, End: position.End})
+			b.push(token.WithPosition(self, position))
+			return
+		} else {
+			tk = self
+		}
+	}
+
+	if def, ok := tk.(token.LinkReferenceDefinition); ok && b.paragraphOpen() {
+		tk = def.ConvertBlockToParagraph()
+	}
+
+	if item, ok := tk.(token.ListItem); ok && b.lastType() == token.ParagraphBlockType && !canInterruptParagraph(item) {
+		tk = token.NewParagraphBlock(line, 0)
+	}
+
+	if b.continueParagraph(tk, position) {
+		return
+	}
+
+	b.collectReference(tk)
+	b.push(token.WithPosition(tk, position))
+}
+
+// paragraphOpen reports whether the held back token ends with a paragraph, which the next line may continue
+func (b *blockBuilder) paragraphOpen() bool {
+	_, ok := appendParagraphLine(b.last, "")
+
+	return ok
+}
+
+// continueParagraph appends the line of the token to the open paragraph when it is paragraph continuation text.
+// A line without quote markers may continue the paragraph of a quote or list item lazily.
+func (b *blockBuilder) continueParagraph(tk token.BlockToken, position token.Position) bool {
+	var text string
+	switch t := tk.(type) {
+	case *token.ParagraphBlock:
+		text = t.InlineString()
+	case token.BlockQuote:
+		last, ok := b.last.(token.BlockQuote)
+		paragraph, isParagraph := t.ContentBlock().(*token.ParagraphBlock)
+		// a deeper quote marker starts a nested quote instead
+		if !ok || !isParagraph || t.Depth() > last.Depth() {
+			return false
+		}
+		text = paragraph.InlineString()
+	default:
+		return false
+	}
+
+	// a lazy line after the indented paragraph of a list item is appended to it
+	if indented, ok := b.last.(*token.IndentedBlock); ok && b.inList && tk.Type() == token.ParagraphBlockType {
+		// the line must be a paragraph of the item rather than indented code in it
+		if indented.Column() >= b.listContent+4 || DetectBlockType(indented.InlineString()).Type() != token.ParagraphBlockType {
+			return false
+		}
+
+		continued := token.NewIndentedBlock(indented.Depth(), []rune(indented.InlineString()+"\n"+strings.TrimLeft(text, " \t")))
+		b.last = token.WithPosition(continued, token.Position{Start: indented.Position().Start, End: position.End})
+
+		return true
+	}
+
+	continued, ok := appendParagraphLine(b.last, text)
+	if !ok {
+		return false
+	}
+
+	b.last = token.WithPosition(continued, token.Position{Start: b.last.Position().Start, End: position.End})
+
+	return true
+}
+
+// appendParagraphLine appends the line to the innermost paragraph of the token
+func appendParagraphLine(tk token.BlockToken, line string) (token.BlockToken, bool) {
+	switch t := tk.(type) {
+	case *token.ParagraphBlock:
+		return t.AppendLine(line), true
+	case token.BlockQuote:
+		content, ok := appendParagraphLine(t.ContentBlock(), line)
+		if !ok {
+			return nil, false
+		}
+		return t.WithContentBlock(content), true
+	case token.ListItem:
+		content, ok := appendParagraphLine(t.ContentBlock(), line)
+		if !ok {
+			return nil, false
+		}
+		return t.WithContentBlock(content), true
+	case token.FootnoteDefinition:
+		content, ok := appendParagraphLine(t.ContentBlock(), line)
+		if !ok {
+			return nil, false
+		}
+		return t.WithContentBlock(content), true
+	}
+
+	return nil, false
+}
+
+// releaseCodeBlanks pushes the blank lines after an indented code block which did not continue
+func (b *blockBuilder) releaseCodeBlanks() {
+	blanks := b.codeBlanks
+	b.codeBlanks = nil
+
+	for _, blank := range blanks {
+		b.push(token.WithPosition(blank.token, blank.position))
+	}
+}
+
+// trimCodeIndent removes the indentation of an indented code block, which is up to four columns, from the line
+func trimCodeIndent(line string) string {
+	columns := 0
+	for i, char := range line {
+		switch {
+		case columns >= 4:
+			return line[i:]
+		case char == ' ':
+			columns++
+		case char == '\t':
+			columns += 4
+		default:
+			return line[i:]
+		}
+	}
+
+	return ""
+}
+
+func (b *blockBuilder) closeCodeBlock(end token.Pos) {
+	codeBlock := token.NewCodeBlock(b.openingCodeBlockFence.InfoString(), b.codeBuffer)
+	b.push(token.WithPosition(codeBlock, token.Position{Start: b.openingCodeBlockStart, End: end}))
+	b.openingCodeBlockFence = nil
+	b.codeBuffer = make([]string, 0)
+}
+
+// trimFenceIndent removes up to indent leading spaces, the indentation of the opening fence, from the code line
+func trimFenceIndent(line string, indent int) string {
+	i := 0
+	for i < indent && i < len(line) && line[i] == ' ' {
+		i++
+	}
+
+	return line[i:]
+}
+
+func (b *blockBuilder) closeHTMLBlock() {
+	b.push(token.WithPosition(token.NewHTMLBlock(b.openingHTMLBlock.Condition(), b.htmlBuffer), b.htmlBlockSpan))
+	b.openingHTMLBlock = nil
+	b.htmlBuffer = nil
+}
+
+// flush emits the held back token at the end of the input.
+// A front matter without a closing fence is not front matter, so its lines are added again as ordinary lines.
+func (b *blockBuilder) flush() {
+	if b.frontMatter != nil {
+		pending := b.frontMatter
+		b.frontMatter = nil
+		b.line, b.offset = 0, 0
+		b.replaying = true
+
+		for i, line := range pending.lines {
+			b.add(line, pending.tokens[i])
+		}
+	}
+
+	// a code block or an HTML block which is not closed runs to the end of the document
+	if b.openingCodeBlockFence != nil {
+		// the empty line after the last newline ends the document rather than adding a code line
+		if n := len(b.codeBuffer); n > 0 && b.codeBuffer[n-1] == "" {
+			b.codeBuffer = b.codeBuffer[:n-1]
+		}
+		b.closeCodeBlock(b.previousLine.End)
+	}
+	if b.openingHTMLBlock != nil {
+		b.closeHTMLBlock()
+	}
+
+	b.releaseCodeBlanks()
+
+	if b.last != nil {
+		b.emit(b.slugs.withHeadingID(b.last))
+		b.last = nil
+	}
+}
+
+// keepsListIndent reports whether the indented line of the token is kept as an IndentedBlock in a list.
+// A paragraph line continuing the open paragraph is appended to it instead, whatever its indentation.
+func keepsListIndent(tk token.BlockToken, paragraphOpen bool) bool {
+	switch tk.(type) {
+	case *token.ParagraphBlock:
+		return !paragraphOpen
+	case *token.HeadingBlock, token.BlockQuote, token.Horizontal, token.SetextHeadingToken, *token.CodeBlockFence, *token.HTMLBlock:
+		return true
+	}
+
+	return false
+}
+
+// continuesTable reports whether the line of the token is a body row of the table above it.
+// A table ends at a blank line or at the start of another block.
+func continuesTable(tk token.BlockToken) bool {
+	switch t := tk.(type) {
+	case *token.ParagraphBlock:
+		return t.Depth() == 0
+	case token.TableDelimiterRow:
+		return true
+	}
+
+	return false
+}
+
+// canInterruptParagraph reports whether the list item may start directly after a paragraph line.
+// Empty items and ordered items not starting at 1 are treated as paragraph text instead.
+func canInterruptParagraph(item token.ListItem) bool {
+	if item.ContentBlock() == nil || item.ContentBlock().Type() == token.BlankBlockType {
+		return false
+	}
+
+	return !item.Ordered() || item.Start() == 1
+}
@@ -0,0 +1,250 @@
+package main
+
+import "strings"
+
+// Highlighter renders code of the language as HTML.
+// The code is the lines of a code block, each terminated by a newline.
+// It returns false for a language it does not know, and the code is then escaped as it is.
+type Highlighter interface {
+	Highlight(language, code string) (string, bool)
+}
+
+// BuiltinHighlighter is a lightweight tokenizer-based highlighter for Go, JSON, YAML and shell.
+// Tokens are wrapped in spans whose classes are hl-keyword, hl-type, hl-literal, hl-number, hl-string,
+// hl-key, hl-variable and hl-comment.
+type BuiltinHighlighter struct{}
+
+func NewBuiltinHighlighter() *BuiltinHighlighter {
+	return &BuiltinHighlighter{}
+}
+
+func (h *BuiltinHighlighter) Highlight(language, code string) (string, bool) {
+	lang, ok := highlightLanguages[strings.ToLower(language)]
+	if !ok {
+		return "", false
+	}
+
+	return lang.highlight(code), true
+}
+
+// highlightLanguage describes the tokens of a language
+type highlightLanguage struct {
+	keywords map[string]bool
+	types    map[string]bool
+	literals map[string]bool
+	// lineComment starts a comment running to the end of the line; `#` only starts one at the start of a word
+	lineComment  string
+	blockComment [2]string
+	quotes       string
+	// wordChars are the characters other than letters, digits and `_` which may continue a word
+	wordChars string
+	// quotedKeys marks a string followed by `:` as a key, and bareKeys marks a word followed by `: ` as a key
+	quotedKeys bool
+	bareKeys   bool
+	variables  bool
+}
+
+var highlightLanguages = func() map[string]*highlightLanguage {
+	golang := &highlightLanguage{
+		keywords:     wordSet("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+		types:        wordSet("any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr"),
+		literals:     wordSet("true false nil iota"),
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+	json := &highlightLanguage{
+		literals:   wordSet("true false null"),
+		quotes:     `"`,
+		wordChars:  ".",
+		quotedKeys: true,
+	}
+	yaml := &highlightLanguage{
+		literals:    wordSet("true false yes no on off null True False Yes No On Off Null TRUE FALSE NULL"),
+		lineComment: "#",
+		quotes:      `"'`,
+		wordChars:   "-.",
+		quotedKeys:  true,
+		bareKeys:    true,
+	}
+	shell := &highlightLanguage{
+		keywords:    wordSet("if then else elif fi for while until do done case esac in function select return export local readonly"),
+		lineComment: "#",
+		quotes:      `"'`,
+		wordChars:   "-.",
+		variables:   true,
+	}
+
+	return map[string]*highlightLanguage{
+		"go":     golang,
+		"golang": golang,
+		"json":   json,
+		"yaml":   yaml,
+		"yml":    yaml,
+		"sh":     shell,
+		"bash":   shell,
+		"shell":  shell,
+		"zsh":    shell,
+	}
+}()
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+
+	return set
+}
+
+func (l *highlightLanguage) highlight(code string) string {
+	var builder strings.Builder
+
+	span := func(class, text string) {
+		builder.WriteString(`<span class="hl-` + class + `">` + escapeHTML(text) + `</span>`)
+	}
+
+	for i := 0; i < len(code); {
+		c := code[i]
+
+		switch {
+		case l.startsLineComment(code, i):
+			end := lineEnd(code, i)
+			span("comment", code[i:end])
+			i = end
+		case l.blockComment[0] != "" && strings.HasPrefix(code[i:], l.blockComment[0]):
+			end := len(code)
+			if j := strings.Index(code[i+len(l.blockComment[0]):], l.blockComment[1]); j >= 0 {
+				end = i + len(l.blockComment[0]) + j + len(l.blockComment[1])
+			}
+			span("comment", code[i:end])
+			i = end
+		case strings.IndexByte(l.quotes, c) >= 0:
+			end := stringEnd(code, i)
+			if l.quotedKeys && l.followedByColon(code, end) {
+				span("key", code[i:end])
+			} else {
+				span("string", code[i:end])
+			}
+			i = end
+		case l.variables && c == '$' && variableEnd(code, i) > i+1:
+			end := variableEnd(code, i)
+			span("variable", code[i:end])
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(code) && (isWordByte(code[end]) || strings.IndexByte(l.wordChars, code[end]) >= 0) {
+				end++
+			}
+
+			word := code[i:end]
+			switch {
+			case l.bareKeys && l.followedByColon(code, end):
+				span("key", word)
+			case isDigit(c):
+				span("number", word)
+			case l.keywords[word]:
+				span("keyword", word)
+			case l.types[word]:
+				span("type", word)
+			case l.literals[word]:
+				span("literal", word)
+			default:
+				builder.WriteString(escapeHTML(word))
+			}
+			i = end
+		default:
+			builder.WriteString(escapeHTML(code[i : i+1]))
+			i++
+		}
+	}
+
+	return builder.String()
+}
+
+func (l *highlightLanguage) startsLineComment(code string, i int) bool {
+	if l.lineComment == "" || !strings.HasPrefix(code[i:], l.lineComment) {
+		return false
+	}
+
+	// `#` in the middle of a word such as `a#b` does not start a comment
+	return l.lineComment != "#" || i == 0 || isHighlightSpace(code[i-1])
+}
+
+// followedByColon reports whether a key separator follows the position.
+// YAML requires a space or the end of the line after the colon, while JSON does not.
+func (l *highlightLanguage) followedByColon(code string, i int) bool {
+	for i < len(code) && (code[i] == ' ' || code[i] == '\t') {
+		i++
+	}
+
+	if i >= len(code) || code[i] != ':' {
+		return false
+	}
+
+	return !l.bareKeys || i+1 == len(code) || isHighlightSpace(code[i+1])
+}
+
+func lineEnd(code string, i int) int {
+	if j := strings.IndexByte(code[i:], '\n'); j >= 0 {
+		return i + j
+	}
+
+	return len(code)
+}
+
+// stringEnd returns the end of the string starting with the quote at i.
+// Backslash escapes the next character except in a raw string quoted by a backtick,
+// which is the only string that may span lines.
+func stringEnd(code string, i int) int {
+	quote := code[i]
+
+	for j := i + 1; j < len(code); j++ {
+		switch {
+		case code[j] == quote:
+			return j + 1
+		case code[j] == '\\' && quote != '`':
+			j++
+		case code[j] == '\n' && quote != '`':
+			return j
+		}
+	}
+
+	return len(code)
+}
+
+// variableEnd returns the end of a shell variable such as `$HOME`, `${HOME}` or `$1` starting at i
+func variableEnd(code string, i int) int {
+	j := i + 1
+	if j >= len(code) {
+		return j
+	}
+
+	switch {
+	case code[j] == '{':
+		if k := strings.IndexByte(code[j:], '}'); k >= 0 {
+			return j + k + 1
+		}
+		return j
+	case isDigit(code[j]) || strings.IndexByte("?!#$@*-", code[j]) >= 0:
+		return j + 1
+	}
+
+	for j < len(code) && isWordByte(code[j]) {
+		j++
+	}
+
+	return j
+}
+
+func isWordByte(c byte) bool {
+	return isASCIILetter(c) || isDigit(c) || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHighlightSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
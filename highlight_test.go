@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestBuiltinHighlighter_Highlight(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		language string
+		code     string
+	}
+	type want struct {
+		html string
+		ok   bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "Go",
+			args: args{"go", "func f() error { return nil } // <done>\n"},
+			want: want{`<span class="hl-keyword">func</span> f() <span class="hl-type">error</span> { <span class="hl-keyword">return</span> <span class="hl-literal">nil</span> } <span class="hl-comment">// &lt;done&gt;</span>` + "\n", true},
+		},
+		{
+			name: "Go raw string across lines",
+			args: args{"Go", "s := `a\nb` /* c */ 1\n"},
+			want: want{"s := <span class=\"hl-string\">`a\nb`</span> <span class=\"hl-comment\">/* c */</span> <span class=\"hl-number\">1</span>\n", true},
+		},
+		{
+			name: "JSON",
+			args: args{"json", `{"a":1.5,"b":["x\"",true]}`},
+			want: want{`{<span class="hl-key">&quot;a&quot;</span>:<span class="hl-number">1.5</span>,<span class="hl-key">&quot;b&quot;</span>:[<span class="hl-string">&quot;x\&quot;&quot;</span>,<span class="hl-literal">true</span>]}`, true},
+		},
+		{
+			name: "YAML",
+			args: args{"yml", "name: a#b # comment\nlist:\n  - 'q'\n  - null\n"},
+			want: want{`<span class="hl-key">name</span>: a#b <span class="hl-comment"># comment</span>` + "\n" + `<span class="hl-key">list</span>:` + "\n" + `  - <span class="hl-string">'q'</span>` + "\n" + `  - <span class="hl-literal">null</span>` + "\n", true},
+		},
+		{
+			name: "Shell",
+			args: args{"bash", "if [ -n \"$1\" ]; then echo ${HOME} $PATH; fi # end\n"},
+			want: want{`<span class="hl-keyword">if</span> [ -n <span class="hl-string">&quot;$1&quot;</span> ]; <span class="hl-keyword">then</span> echo <span class="hl-variable">${HOME}</span> <span class="hl-variable">$PATH</span>; <span class="hl-keyword">fi</span> <span class="hl-comment"># end</span>` + "\n", true},
+		},
+		{
+			name: "Unknown language",
+			args: args{"rust", "fn main() {}\n"},
+			want: want{"", false},
+		},
+		{
+			name: "No language",
+			args: args{"", "code\n"},
+			want: want{"", false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := NewBuiltinHighlighter().Highlight(tt.args.language, tt.args.code)
+			if got != tt.want.html || ok != tt.want.ok {
+				t.Errorf("BuiltinHighlighter.Highlight() = %q, %v, want %q, %v", got, ok, tt.want.html, tt.want.ok)
+			}
+		})
+	}
+}
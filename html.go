@@ -0,0 +1,561 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// ErrUnsupportedBlock is returned when the document contains a block token the renderer cannot write
+var ErrUnsupportedBlock = errors.New("unsupported block token")
+
+var htmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// escapeURL percent-encodes the characters which are not allowed in a URL and escapes the result for an attribute
+func escapeURL(url string) string {
+	const hex = "0123456789ABCDEF"
+	const safe = ";/?:@&=+$,-_.!~*'()#"
+
+	var builder strings.Builder
+
+	for i := 0; i < len(url); i++ {
+		c := url[i]
+
+		switch {
+		case c == '%' && i+2 < len(url) && isHexDigit(url[i+1]) && isHexDigit(url[i+2]):
+			builder.WriteByte(c)
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', strings.IndexByte(safe, c) >= 0:
+			builder.WriteByte(c)
+		default:
+			builder.WriteByte('%')
+			builder.WriteByte(hex[c>>4])
+			builder.WriteByte(hex[c&0x0f])
+		}
+	}
+
+	return escapeHTML(builder.String())
+}
+
+// footnoteID returns the part of the footnote ids following `fn-` and `fnref-`,
+// which is the normalized label in lower case with every byte other than an unreserved URL character percent-encoded
+func footnoteID(label string) string {
+	const hex = "0123456789ABCDEF"
+
+	normalized := strings.ToLower(inline.NormalizeLabel(label))
+
+	var builder strings.Builder
+	for i := 0; i < len(normalized); i++ {
+		c := normalized[i]
+
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', strings.IndexByte("-_.~", c) >= 0:
+			builder.WriteByte(c)
+		default:
+			builder.WriteByte('%')
+			builder.WriteByte(hex[c>>4])
+			builder.WriteByte(hex[c&0x0f])
+		}
+	}
+
+	return builder.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// htmlWriter keeps the first write error so that rendering does not need to check every write
+type htmlWriter struct {
+	w    io.Writer
+	last byte
+	err  error
+}
+
+func (hw *htmlWriter) write(s string) {
+	if hw.err != nil || s == "" {
+		return
+	}
+
+	_, hw.err = io.WriteString(hw.w, s)
+	hw.last = s[len(s)-1]
+}
+
+// cr starts a new line unless the output is already at the beginning of one
+func (hw *htmlWriter) cr() {
+	if hw.last != 0 && hw.last != '\n' {
+		hw.write("\n")
+	}
+}
+
+type HTMLRenderer struct {
+	inlineParser *inline.Parser
+	highlighter  Highlighter
+	headingIDs   bool
+	commonMark   bool
+	// indentedCodeLanguage is the language of the indented code blocks, which have no info string to name one
+	indentedCodeLanguage string
+
+	// footnotes holds the definitions by normalized label,
+	// footnoteOrder the labels in the order of their first reference and footnoteRefs the count of their references
+	footnotes     map[string]token.FootnoteDefinition
+	footnoteOrder []string
+	footnoteRefs  map[string]int
+}
+
+func NewHTMLRenderer() *HTMLRenderer {
+	return &HTMLRenderer{}
+}
+
+// WithHighlighter returns a copy of the renderer highlighting the code blocks with the highlighter
+func (r HTMLRenderer) WithHighlighter(highlighter Highlighter) *HTMLRenderer {
+	r.highlighter = highlighter
+	return &r
+}
+
+// WithIndentedCodeLanguage returns a copy of the renderer treating the indented code blocks as code of the language,
+// which is given to the highlighter and written as their class like the language of a fenced code block.
+// Without it, indented code is passed to the highlighter with an empty language.
+func (r HTMLRenderer) WithIndentedCodeLanguage(language string) *HTMLRenderer {
+	r.indentedCodeLanguage = language
+	return &r
+}
+
+// WithHeadingIDs returns a copy of the renderer writing the anchor IDs of the headings as id attributes
+func (r HTMLRenderer) WithHeadingIDs() *HTMLRenderer {
+	r.headingIDs = true
+	return &r
+}
+
+// WithCommonMark returns a copy of the renderer parsing the inline text without the GFM extended autolinks and strikethrough
+func (r HTMLRenderer) WithCommonMark() *HTMLRenderer {
+	r.commonMark = true
+	return &r
+}
+
+// RenderHTML writes the document as HTML to w
+func RenderHTML(w io.Writer, doc *token.Document) error {
+	return NewHTMLRenderer().Render(w, doc)
+}
+
+func (r *HTMLRenderer) Render(w io.Writer, doc *token.Document) error {
+	r.footnotes = make(map[string]token.FootnoteDefinition)
+	r.footnoteOrder = make([]string, 0)
+	r.footnoteRefs = make(map[string]int)
+
+	footnotes := make(inline.Footnotes)
+	r.collectFootnotes(doc.Children(), footnotes)
+	r.inlineParser = inline.NewParser(doc.References()).WithFootnotes(footnotes)
+	if r.commonMark {
+		r.inlineParser = r.inlineParser.WithCommonMark()
+	}
+
+	hw := &htmlWriter{w: w}
+	r.renderBlocks(hw, doc.Children(), false)
+	r.renderFootnotes(hw)
+
+	return hw.err
+}
+
+// collectFootnotes registers the footnote definitions found anywhere in the blocks, the first definition of a label taking precedence
+func (r *HTMLRenderer) collectFootnotes(blocks []token.BlockToken, footnotes inline.Footnotes) {
+	for _, block := range blocks {
+		switch tk := block.(type) {
+		case token.FootnoteDefinition:
+			key := inline.NormalizeLabel(tk.Label())
+			if _, ok := r.footnotes[key]; !ok {
+				r.footnotes[key] = tk
+				footnotes.Add(tk.Label())
+			}
+			r.collectFootnotes(tk.Children(), footnotes)
+		case token.BlockQuote:
+			r.collectFootnotes(tk.Children(), footnotes)
+		case token.List:
+			r.collectFootnotes(tk.Children(), footnotes)
+		case token.ListItem:
+			r.collectFootnotes(tk.Children(), footnotes)
+		}
+	}
+}
+
+// renderFootnotes writes the section of the referenced footnotes numbered in the order of their first reference.
+// A footnote referenced only from another footnote is appended while the section is written.
+func (r *HTMLRenderer) renderFootnotes(hw *htmlWriter) {
+	if len(r.footnoteOrder) == 0 {
+		return
+	}
+
+	hw.cr()
+	hw.write(`<section class="footnotes" data-footnotes>`)
+	hw.cr()
+	hw.write("<ol>")
+	hw.cr()
+
+	for i := 0; i < len(r.footnoteOrder); i++ {
+		key := r.footnoteOrder[i]
+		definition := r.footnotes[key]
+		id := footnoteID(definition.Label())
+
+		backrefs := make([]string, 0, r.footnoteRefs[key])
+		for ref := 1; ref <= r.footnoteRefs[key]; ref++ {
+			idx := strconv.Itoa(i + 1)
+			refID := id
+			mark := "↩"
+			if ref > 1 {
+				idx += "-" + strconv.Itoa(ref)
+				refID += "-" + strconv.Itoa(ref)
+				mark += fmt.Sprintf(`<sup class="footnote-ref">%d</sup>`, ref)
+			}
+			backrefs = append(backrefs, fmt.Sprintf(`<a href="#fnref-%s" class="footnote-backref" data-footnote-backref data-footnote-backref-idx="%s" aria-label="Back to reference %s">%s</a>`, refID, idx, idx, mark))
+		}
+		backref := " " + strings.Join(backrefs, " ")
+
+		hw.write(`<li id="fn-` + id + `">`)
+		hw.cr()
+
+		// the back-references are placed inside the last paragraph of the footnote
+		children := definition.Children()
+		if paragraph, ok := lastParagraph(children); ok {
+			r.renderBlocks(hw, children[:len(children)-1], false)
+			r.renderParagraph(hw, paragraph, false, "", backref)
+		} else {
+			r.renderBlocks(hw, children, false)
+			hw.cr()
+			hw.write("<p>" + strings.TrimPrefix(backref, " ") + "</p>")
+			hw.cr()
+		}
+
+		hw.write("</li>")
+		hw.cr()
+	}
+
+	hw.write("</ol>")
+	hw.cr()
+	hw.write("</section>")
+	hw.cr()
+}
+
+func (r *HTMLRenderer) renderBlocks(hw *htmlWriter, blocks []token.BlockToken, tight bool) {
+	for _, block := range blocks {
+		r.renderBlock(hw, block, tight)
+	}
+}
+
+func (r *HTMLRenderer) renderBlock(hw *htmlWriter, block token.BlockToken, tight bool) {
+	switch tk := block.(type) {
+	case *token.HeadingBlock:
+		hw.cr()
+		if r.headingIDs && tk.ID() != "" {
+			hw.write(fmt.Sprintf(`<h%d id="%s">`, tk.Level(), escapeHTML(tk.ID())))
+		} else {
+			hw.write(fmt.Sprintf("<h%d>", tk.Level()))
+		}
+		r.renderInlines(hw, r.inlineParser.Parse(strings.TrimSpace(tk.InlineString())))
+		hw.write(fmt.Sprintf("</h%d>", tk.Level()))
+		hw.cr()
+	case *token.ParagraphBlock:
+		r.renderParagraph(hw, tk, tight, "", "")
+	case token.Horizontal:
+		hw.cr()
+		hw.write("<hr />")
+		hw.cr()
+	case *token.CodeBlock:
+		hw.cr()
+		info := tk.Info()
+		hw.write(codeBlockOpening(info))
+		r.renderCode(hw, info.Language(), tk.CodeLines())
+		hw.write("</code></pre>")
+		hw.cr()
+	case *token.IndentedCodeBlock:
+		hw.cr()
+		if r.indentedCodeLanguage != "" {
+			hw.write(`<pre><code class="language-` + escapeHTML(r.indentedCodeLanguage) + `">`)
+		} else {
+			hw.write("<pre><code>")
+		}
+		r.renderCode(hw, r.indentedCodeLanguage, tk.CodeLines())
+		hw.write("</code></pre>")
+		hw.cr()
+	case *token.HTMLBlock:
+		hw.cr()
+		hw.write(strings.Join(tk.Lines(), "\n"))
+		hw.cr()
+	case token.BlockQuote:
+		hw.cr()
+		hw.write("<blockquote>")
+		hw.cr()
+		r.renderBlocks(hw, tk.Children(), false)
+		hw.cr()
+		hw.write("</blockquote>")
+		hw.cr()
+	case token.List:
+		tag := "ul"
+		hw.cr()
+		if tk.Ordered() {
+			tag = "ol"
+			if tk.Start() != 1 {
+				hw.write(fmt.Sprintf(`<ol start="%d">`, tk.Start()))
+			} else {
+				hw.write("<ol>")
+			}
+		} else {
+			hw.write("<ul>")
+		}
+		hw.cr()
+		for _, item := range tk.Children() {
+			r.renderBlock(hw, item, tk.Tight())
+		}
+		hw.cr()
+		hw.write("</" + tag + ">")
+		hw.cr()
+	case *token.Table:
+		hw.cr()
+		hw.write("<table>")
+		hw.cr()
+		hw.write("<thead>")
+		hw.cr()
+		r.renderTableRow(hw, "th", tk.Header(), tk.Alignments())
+		hw.write("</thead>")
+		hw.cr()
+		if len(tk.Rows()) > 0 {
+			hw.write("<tbody>")
+			hw.cr()
+			for _, row := range tk.Rows() {
+				r.renderTableRow(hw, "td", row, tk.Alignments())
+			}
+			hw.write("</tbody>")
+			hw.cr()
+		}
+		hw.write("</table>")
+		hw.cr()
+	case token.FootnoteDefinition:
+		// footnotes are written in their own section at the end of the document
+	case token.ListItem:
+		hw.write("<li>")
+		children := tk.Children()
+		if tk.Task() {
+			checkbox := `<input disabled="" type="checkbox" /> `
+			if tk.Checked() {
+				checkbox = `<input checked="" disabled="" type="checkbox" /> `
+			}
+
+			// the checkbox is placed inside the first paragraph of the item
+			if paragraph, ok := firstParagraph(children); ok {
+				r.renderParagraph(hw, paragraph, tight, checkbox, "")
+				children = children[1:]
+			} else {
+				hw.write(checkbox)
+			}
+		}
+		r.renderBlocks(hw, children, tight)
+		hw.write("</li>")
+		hw.cr()
+	case token.Blank, token.SetextHeading, token.LinkReferenceDefinition, *token.FrontMatter:
+		// these blocks have no output of their own
+	default:
+		if hw.err == nil {
+			hw.err = fmt.Errorf("%w: %q", ErrUnsupportedBlock, block.Type())
+		}
+	}
+}
+
+// renderCode writes the code lines, highlighted when the highlighter knows the language
+func (r *HTMLRenderer) renderCode(hw *htmlWriter, language string, lines []string) {
+	var code strings.Builder
+	for _, line := range lines {
+		code.WriteString(line + "\n")
+	}
+
+	if r.highlighter != nil {
+		if highlighted, ok := r.highlighter.Highlight(language, code.String()); ok {
+			hw.write(highlighted)
+			return
+		}
+	}
+
+	hw.write(escapeHTML(code.String()))
+}
+
+// codeBlockOpening returns the opening tags of a fenced code block.
+// The id, the highlighted lines and the other attributes such as the title are put on the pre element,
+// following the data-line convention of the line highlighting plugins.
+func codeBlockOpening(info token.CodeInfo) string {
+	var builder strings.Builder
+
+	builder.WriteString("<pre")
+	if info.ID() != "" {
+		builder.WriteString(` id="` + escapeHTML(info.ID()) + `"`)
+	}
+	if len(info.Lines()) > 0 {
+		lines := make([]string, 0, len(info.Lines()))
+		for _, lineRange := range info.Lines() {
+			lines = append(lines, lineRange.String())
+		}
+		builder.WriteString(` data-line="` + strings.Join(lines, ",") + `"`)
+	}
+
+	attributes := info.Attributes()
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		builder.WriteString(` data-` + escapeHTML(key) + `="` + escapeHTML(attributes[key]) + `"`)
+	}
+
+	builder.WriteString("><code")
+
+	classes := make([]string, 0, len(info.Classes())+1)
+	if info.Language() != "" {
+		classes = append(classes, "language-"+info.Language())
+	}
+	classes = append(classes, info.Classes()...)
+	if len(classes) > 0 {
+		builder.WriteString(` class="` + escapeHTML(strings.Join(classes, " ")) + `"`)
+	}
+
+	builder.WriteString(">")
+
+	return builder.String()
+}
+
+func firstParagraph(blocks []token.BlockToken) (*token.ParagraphBlock, bool) {
+	if len(blocks) == 0 {
+		return nil, false
+	}
+
+	paragraph, ok := blocks[0].(*token.ParagraphBlock)
+
+	return paragraph, ok
+}
+
+func lastParagraph(blocks []token.BlockToken) (*token.ParagraphBlock, bool) {
+	if len(blocks) == 0 {
+		return nil, false
+	}
+
+	paragraph, ok := blocks[len(blocks)-1].(*token.ParagraphBlock)
+
+	return paragraph, ok
+}
+
+// renderParagraph writes the paragraph, whose <p> tags are omitted inside tight lists,
+// with the prefix put before its text and the suffix after it
+func (r *HTMLRenderer) renderParagraph(hw *htmlWriter, paragraph *token.ParagraphBlock, tight bool, prefix string, suffix string) {
+	inlines := r.inlineParser.Parse(strings.TrimSpace(paragraph.InlineString()))
+	if tight {
+		hw.write(prefix)
+		r.renderInlines(hw, inlines)
+		hw.write(suffix)
+		return
+	}
+
+	hw.cr()
+	hw.write("<p>" + prefix)
+	r.renderInlines(hw, inlines)
+	hw.write(suffix + "</p>")
+	hw.cr()
+}
+
+func (r *HTMLRenderer) renderTableRow(hw *htmlWriter, tag string, cells []string, alignments []token.Alignment) {
+	hw.write("<tr>")
+	hw.cr()
+	for i, cell := range cells {
+		if i < len(alignments) && alignments[i] != token.AlignNone {
+			hw.write(fmt.Sprintf(`<%s align="%s">`, tag, alignments[i]))
+		} else {
+			hw.write("<" + tag + ">")
+		}
+		r.renderInlines(hw, r.inlineParser.Parse(cell))
+		hw.write("</" + tag + ">")
+		hw.cr()
+	}
+	hw.write("</tr>")
+	hw.cr()
+}
+
+func (r *HTMLRenderer) renderInlines(hw *htmlWriter, inlines []inline.Token) {
+	for _, tk := range inlines {
+		r.renderInline(hw, tk)
+	}
+}
+
+func (r *HTMLRenderer) renderInline(hw *htmlWriter, tk inline.Token) {
+	switch t := tk.(type) {
+	case inline.Text:
+		hw.write(escapeHTML(t.Content()))
+	case inline.CodeSpan:
+		hw.write("<code>" + escapeHTML(t.Code()) + "</code>")
+	case inline.SoftBreak:
+		hw.write("\n")
+	case inline.HardBreak:
+		hw.write("<br />\n")
+	case inline.Strikethrough:
+		hw.write("<del>")
+		r.renderInlines(hw, t.Children())
+		hw.write("</del>")
+	case inline.Emphasis:
+		hw.write("<em>")
+		r.renderInlines(hw, t.Children())
+		hw.write("</em>")
+	case inline.Strong:
+		hw.write("<strong>")
+		r.renderInlines(hw, t.Children())
+		hw.write("</strong>")
+	case inline.Link:
+		hw.write(`<a href="` + escapeURL(t.Destination()) + `"`)
+		if t.Title() != "" {
+			hw.write(` title="` + escapeHTML(t.Title()) + `"`)
+		}
+		hw.write(">")
+		r.renderInlines(hw, t.Children())
+		hw.write("</a>")
+	case inline.Image:
+		hw.write(`<img src="` + escapeURL(t.Destination()) + `" alt="` + escapeHTML(t.Alt()) + `"`)
+		if t.Title() != "" {
+			hw.write(` title="` + escapeHTML(t.Title()) + `"`)
+		}
+		hw.write(" />")
+	case inline.Autolink:
+		hw.write(`<a href="` + escapeURL(t.Destination()) + `">` + escapeHTML(t.Content()) + "</a>")
+	case inline.FootnoteReference:
+		r.renderFootnoteReference(hw, t)
+	}
+}
+
+// renderFootnoteReference writes the reference numbered by the first reference of its footnote.
+// The second and later references to a footnote get ids suffixed with -2, -3 and so on for their back-references.
+func (r *HTMLRenderer) renderFootnoteReference(hw *htmlWriter, tk inline.FootnoteReference) {
+	key := inline.NormalizeLabel(tk.Label())
+	definition := r.footnotes[key]
+
+	number := slices.Index(r.footnoteOrder, key) + 1
+	if number == 0 {
+		r.footnoteOrder = append(r.footnoteOrder, key)
+		number = len(r.footnoteOrder)
+	}
+	r.footnoteRefs[key]++
+
+	id := footnoteID(definition.Label())
+	refID := id
+	if ref := r.footnoteRefs[key]; ref > 1 {
+		refID += "-" + strconv.Itoa(ref)
+	}
+
+	hw.write(fmt.Sprintf(`<sup class="footnote-ref"><a href="#fn-%s" id="fnref-%s" data-footnote-ref>%d</a></sup>`, id, refID, number))
+}
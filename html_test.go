@@ -0,0 +1,430 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+func TestHTMLRenderer_Render(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Heading",
+			input: "## Heading *em*",
+			want:  "<h2>Heading <em>em</em></h2>\n",
+		},
+		{
+			name:  "Setext heading",
+			input: "Heading\n===",
+			want:  "<h1>Heading</h1>\n",
+		},
+		{
+			name:  "Paragraph with escaped text",
+			input: `a < b & "c"`,
+			want:  "<p>a &lt; b &amp; &quot;c&quot;</p>\n",
+		},
+		{
+			name:  "Escapes and entities",
+			input: "\\<b\\> &lt;b&gt; &copy; &#123;",
+			want:  "<p>&lt;b&gt; &lt;b&gt; © {</p>\n",
+		},
+		{
+			name:  "Line breaks",
+			input: "soft\nhard  \nbackslash\\\nend",
+			want:  "<p>soft\nhard<br />\nbackslash<br />\nend</p>\n",
+		},
+		{
+			name:  "Lazy quote",
+			input: "> quote\ncontinued",
+			want:  "<blockquote>\n<p>quote\ncontinued</p>\n</blockquote>\n",
+		},
+		{
+			name:  "Thematic break in quote",
+			input: "> ---",
+			want:  "<blockquote>\n<hr />\n</blockquote>\n",
+		},
+		{
+			name:  "Setext heading in quote",
+			input: "> a\n> ---",
+			want:  "<blockquote>\n<h2>a</h2>\n</blockquote>\n",
+		},
+		{
+			name:  "Heading and thematic break in quote",
+			input: "> # h\n> ---",
+			want:  "<blockquote>\n<h1>h</h1>\n<hr />\n</blockquote>\n",
+		},
+		{
+			name:  "Equal signs as list item",
+			input: "- foo\n- ===",
+			want:  "<ul>\n<li>foo</li>\n<li>===</li>\n</ul>\n",
+		},
+		{
+			name:  "Setext heading in list item",
+			input: "- a\n  ---",
+			want:  "<ul>\n<li>\n<h2>a</h2>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Fenced code in list item",
+			input: "- ```\n  code\n  ```\n\nParagraph after\n\n# Heading\n",
+			want:  "<ul>\n<li>\n<pre><code>code\n</code></pre>\n</li>\n</ul>\n<p>Paragraph after</p>\n<h1>Heading</h1>\n",
+		},
+		{
+			name:  "Fenced code after paragraph in list item",
+			input: "- a\n\n  ```go\n  x := 1\n  ```",
+			want:  "<ul>\n<li>\n<p>a</p>\n<pre><code class=\"language-go\">x := 1\n</code></pre>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Fenced code in ordered list item",
+			input: "1. ```\n   foo\n   ```\n\n   bar\n",
+			want:  "<ol>\n<li>\n<pre><code>foo\n</code></pre>\n<p>bar</p>\n</li>\n</ol>\n",
+		},
+		{
+			name:  "Fenced code in quote",
+			input: "> ```\n> code\n> ```",
+			want:  "<blockquote>\n<pre><code>code\n</code></pre>\n</blockquote>\n",
+		},
+		{
+			name:  "Unclosed fenced code in quote",
+			input: "> ```\n> aaa\n\nbbb\n",
+			want:  "<blockquote>\n<pre><code>aaa\n</code></pre>\n</blockquote>\n<p>bbb</p>\n",
+		},
+		{
+			name:  "HTML block in quote",
+			input: "> <div>\n> x",
+			want:  "<blockquote>\n<div>\nx\n</blockquote>\n",
+		},
+		{
+			name:  "HTML block after paragraph in list item",
+			input: "- a\n  <div>\n  x\n\n  y\n\nz",
+			want:  "<ul>\n<li>\n<p>a</p>\n<div>\nx\n<p>y</p>\n</li>\n</ul>\n<p>z</p>\n",
+		},
+		{
+			name:  "Indented code",
+			input: "    a\n\n      b\n\n- item\n\n        c\n\n        d",
+			want:  "<pre><code>a\n\n  b\n</code></pre>\n<ul>\n<li>\n<p>item</p>\n<pre><code>  c\n\n  d\n</code></pre>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Footnotes",
+			input: "A[^b] and[^a][^b].\n\n[^a]: First\n[^b]: Second\n\n    More\n\n[^unused]: Unused",
+			want: "<p>A<sup class=\"footnote-ref\"><a href=\"#fn-b\" id=\"fnref-b\" data-footnote-ref>1</a></sup>" +
+				" and<sup class=\"footnote-ref\"><a href=\"#fn-a\" id=\"fnref-a\" data-footnote-ref>2</a></sup>" +
+				"<sup class=\"footnote-ref\"><a href=\"#fn-b\" id=\"fnref-b-2\" data-footnote-ref>1</a></sup>.</p>\n" +
+				"<section class=\"footnotes\" data-footnotes>\n<ol>\n" +
+				"<li id=\"fn-b\">\n<p>Second</p>\n" +
+				"<p>More <a href=\"#fnref-b\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a>" +
+				" <a href=\"#fnref-b-2\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1-2\" aria-label=\"Back to reference 1-2\">↩<sup class=\"footnote-ref\">2</sup></a></p>\n</li>\n" +
+				"<li id=\"fn-a\">\n<p>First <a href=\"#fnref-a\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"2\" aria-label=\"Back to reference 2\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n",
+		},
+		{
+			name:  "Footnote label with punctuation",
+			input: "Text[^x#y]\n\n[^X#Y]: Note",
+			want: "<p>Text<sup class=\"footnote-ref\"><a href=\"#fn-x%23y\" id=\"fnref-x%23y\" data-footnote-ref>1</a></sup></p>\n" +
+				"<section class=\"footnotes\" data-footnotes>\n<ol>\n" +
+				"<li id=\"fn-x%23y\">\n<p>Note <a href=\"#fnref-x%23y\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n",
+		},
+		{
+			name:  "Undefined footnote",
+			input: "Text[^missing]",
+			want:  "<p>Text[^missing]</p>\n",
+		},
+		{
+			name:  "Tabs and partial indentation",
+			input: "   # Heading\n\n-\tfoo\n\n\tbar\n\n>\t\tcode",
+			want:  "<h1>Heading</h1>\n<ul>\n<li>\n<p>foo</p>\n<p>bar</p>\n</li>\n</ul>\n<blockquote>\n<pre><code>  code\n</code></pre>\n</blockquote>\n",
+		},
+		{
+			name:  "List nested by content column",
+			input: "- a\n  - b\n    - c\n      - d",
+			want:  "<ul>\n<li>a\n<ul>\n<li>b\n<ul>\n<li>c\n<ul>\n<li>d</li>\n</ul>\n</li>\n</ul>\n</li>\n</ul>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "List nested with tabs",
+			input: " - foo\n   - bar\n\t - baz",
+			want:  "<ul>\n<li>foo\n<ul>\n<li>bar\n<ul>\n<li>baz</li>\n</ul>\n</li>\n</ul>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "List item continued at the content column",
+			input: "-  foo\n\n   bar",
+			want:  "<ul>\n<li>\n<p>foo</p>\n<p>bar</p>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Partly consumed tab in list item",
+			input: "- foo\n\n\t\tbar",
+			want:  "<ul>\n<li>\n<p>foo</p>\n<pre><code>  bar\n</code></pre>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Horizontal",
+			input: "***",
+			want:  "<hr />\n",
+		},
+		{
+			name:  "CodeBlock with info string",
+			input: "```go title\nfmt.Println(\"<hi>\")\n```",
+			want:  "<pre><code class=\"language-go\">fmt.Println(&quot;&lt;hi&gt;&quot;)\n</code></pre>\n",
+		},
+		{
+			name:  "CodeBlock with title and highlighted lines",
+			input: "```go title=\"main.go\" {1,3-5}\npackage main\n```",
+			want:  "<pre data-line=\"1,3-5\" data-title=\"main.go\"><code class=\"language-go\">package main\n</code></pre>\n",
+		},
+		{
+			name:  "CodeBlock with attribute block",
+			input: "```{.python .numberLines #hello}\nprint()\n```",
+			want:  "<pre id=\"hello\"><code class=\"language-python numberLines\">print()\n</code></pre>\n",
+		},
+		{
+			name:  "Empty CodeBlock",
+			input: "```\n```",
+			want:  "<pre><code></code></pre>\n",
+		},
+		{
+			name:  "IndentedCodeBlock",
+			input: "        nested",
+			want:  "<pre><code>    nested\n</code></pre>\n",
+		},
+		{
+			name:  "BlockQuote",
+			input: "> quote\n>\n> second",
+			want:  "<blockquote>\n<p>quote</p>\n<p>second</p>\n</blockquote>\n",
+		},
+		{
+			name:  "HTML block",
+			input: "<table>\n  <tr><td>*raw*</td></tr>\n</table>\n\n*md*",
+			want:  "<table>\n  <tr><td>*raw*</td></tr>\n</table>\n<p><em>md</em></p>\n",
+		},
+		{
+			name:  "Front matter",
+			input: "---\ntitle: Title\n---\nText",
+			want:  "<p>Text</p>\n",
+		},
+		{
+			name:  "Task list",
+			input: "- [ ] todo\n- [x] *done*",
+			want:  "<ul>\n<li><input disabled=\"\" type=\"checkbox\" /> todo</li>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\" /> <em>done</em></li>\n</ul>\n",
+		},
+		{
+			name:  "Loose task list",
+			input: "- [x] a\n\n- [ ] b",
+			want:  "<ul>\n<li>\n<p><input checked=\"\" disabled=\"\" type=\"checkbox\" /> a</p>\n</li>\n<li>\n<p><input disabled=\"\" type=\"checkbox\" /> b</p>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Table",
+			input: "| a | `b\\|c` |\n| :-: | --- |\n| *1* |",
+			want:  "<table>\n<thead>\n<tr>\n<th align=\"center\">a</th>\n<th><code>b|c</code></th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"center\"><em>1</em></td>\n<td></td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:  "Table without body",
+			input: "a | b\n--|--",
+			want:  "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n</table>\n",
+		},
+		{
+			name:  "Table in quote",
+			input: "> | a |\n> |---|\n> | 1 |",
+			want:  "<blockquote>\n<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n</tr>\n</tbody>\n</table>\n</blockquote>\n",
+		},
+		{
+			name:  "Table in list item",
+			input: "- x\n\n  | a |\n  |---|\n  | 1 |\n- y",
+			want:  "<ul>\n<li>\n<p>x</p>\n<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n</tr>\n</tbody>\n</table>\n</li>\n<li>\n<p>y</p>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Delimiter row without header in quote",
+			input: "> |---|",
+			want:  "<blockquote>\n<p>|---|</p>\n</blockquote>\n",
+		},
+		{
+			name:  "Tight list",
+			input: "- a\n- b",
+			want:  "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n",
+		},
+		{
+			name:  "Loose list",
+			input: "- a\n\n- b",
+			want:  "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Nested list",
+			input: "- a\n    - b",
+			want:  "<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul>\n</li>\n</ul>\n",
+		},
+		{
+			name:  "Ordered list",
+			input: "1. a\n2. b",
+			want:  "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n",
+		},
+		{
+			name:  "Ordered list with start number",
+			input: "3) a\n4) b\n1. c",
+			want:  "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n<ol>\n<li>c</li>\n</ol>\n",
+		},
+		{
+			name:  "Link with escaped URL and title",
+			input: `[a&b](</my path?q=1&r=2> "t&t")`,
+			want:  "<p><a href=\"/my%20path?q=1&amp;r=2\" title=\"t&amp;t\">a&amp;b</a></p>\n",
+		},
+		{
+			name:  "Reference link",
+			input: "[foo]\n\n[foo]: /url",
+			want:  "<p><a href=\"/url\">foo</a></p>\n",
+		},
+		{
+			name:  "Image",
+			input: `![*alt*](/img.png "title")`,
+			want:  "<p><img src=\"/img.png\" alt=\"alt\" title=\"title\" /></p>\n",
+		},
+		{
+			name:  "Autolink",
+			input: "<https://example.com/ä>",
+			want:  "<p><a href=\"https://example.com/%C3%A4\">https://example.com/ä</a></p>\n",
+		},
+		{
+			name:  "Extended autolink",
+			input: "See www.example.com/a?b=1&c=2.",
+			want:  "<p>See <a href=\"http://www.example.com/a?b=1&amp;c=2\">www.example.com/a?b=1&amp;c=2</a>.</p>\n",
+		},
+		{
+			name:  "Strikethrough",
+			input: "# ~~Old~~ New",
+			want:  "<h1><del>Old</del> New</h1>\n",
+		},
+		{
+			name:  "Footnote defined in list item",
+			input: "Text[^1]\n\n- [^1]: x",
+			want: "<p>Text<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\" data-footnote-ref>1</a></sup></p>\n" +
+				"<ul>\n<li></li>\n</ul>\n" +
+				"<section class=\"footnotes\" data-footnotes>\n<ol>\n" +
+				"<li id=\"fn-1\">\n<p>x <a href=\"#fnref-1\" class=\"footnote-backref\" data-footnote-backref data-footnote-backref-idx=\"1\" aria-label=\"Back to reference 1\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n",
+		},
+		{
+			name:  "CodeSpan",
+			input: "`<tag>`",
+			want:  "<p><code>&lt;tag&gt;</code></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var builder strings.Builder
+			if err := RenderHTML(&builder, NewParser(tt.input).ParseToDocument()); err != nil {
+				t.Fatalf("RenderHTML() error = %v", err)
+			}
+
+			if got := builder.String(); got != tt.want {
+				t.Errorf("RenderHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestHTMLRenderer_RenderWriteError(t *testing.T) {
+	t.Parallel()
+
+	if err := RenderHTML(failingWriter{}, NewParser("# Heading").ParseToDocument()); err == nil {
+		t.Error("RenderHTML() error = nil, want write error")
+	}
+}
+
+func TestHTMLRenderer_RenderUnsupportedBlock(t *testing.T) {
+	t.Parallel()
+
+	doc := token.NewDocument([]token.BlockToken{token.NewParagraphBlock("before", 0), token.NewHyphen(true, []rune("---"))}, inline.References{})
+
+	if err := RenderHTML(&strings.Builder{}, doc); !errors.Is(err, ErrUnsupportedBlock) {
+		t.Errorf("RenderHTML() error = %v, want %v", err, ErrUnsupportedBlock)
+	}
+}
+
+// upperHighlighter highlights the code of the language "upper" by converting it to upper case
+type upperHighlighter struct {
+	languages *[]string
+}
+
+func (h upperHighlighter) Highlight(language, code string) (string, bool) {
+	*h.languages = append(*h.languages, language)
+	if language != "upper" {
+		return "", false
+	}
+
+	return strings.ToUpper(code), true
+}
+
+func TestHTMLRenderer_RenderWithHighlighter(t *testing.T) {
+	t.Parallel()
+
+	languages := make([]string, 0)
+	renderer := NewHTMLRenderer().WithHighlighter(upperHighlighter{&languages})
+
+	var b strings.Builder
+	if err := renderer.Render(&b, NewParser("```upper title=a\ncode\n```\n```go\n<b>\n```\n\n    indented").ParseToDocument()); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+
+	want := "<pre data-title=\"a\"><code class=\"language-upper\">CODE\n</code></pre>\n" +
+		"<pre><code class=\"language-go\">&lt;b&gt;\n</code></pre>\n" +
+		"<pre><code>indented\n</code></pre>\n"
+	if got := b.String(); got != want {
+		t.Errorf("HTMLRenderer.Render() = %q, want %q", got, want)
+	}
+
+	if wantLanguages := []string{"upper", "go", ""}; !reflect.DeepEqual(languages, wantLanguages) {
+		t.Errorf("Highlighter.Highlight() languages = %q, want %q", languages, wantLanguages)
+	}
+}
+
+func TestHTMLRenderer_RenderWithIndentedCodeLanguage(t *testing.T) {
+	t.Parallel()
+
+	languages := make([]string, 0)
+	renderer := NewHTMLRenderer().WithHighlighter(upperHighlighter{&languages}).WithIndentedCodeLanguage("upper")
+
+	var b strings.Builder
+	if err := renderer.Render(&b, NewParser("    indented\n\n```\nfenced\n```").ParseToDocument()); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+
+	want := "<pre><code class=\"language-upper\">INDENTED\n</code></pre>\n" +
+		"<pre><code>fenced\n</code></pre>\n"
+	if got := b.String(); got != want {
+		t.Errorf("HTMLRenderer.Render() = %q, want %q", got, want)
+	}
+
+	if wantLanguages := []string{"upper", ""}; !reflect.DeepEqual(languages, wantLanguages) {
+		t.Errorf("Highlighter.Highlight() languages = %q, want %q", languages, wantLanguages)
+	}
+}
+
+func TestHTMLRenderer_RenderWithHeadingIDs(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	if err := NewHTMLRenderer().WithHeadingIDs().Render(&b, NewParser("# Title\n\nTitle\n---\n> ## *Quoted* title\n\n- # In list\n\n  # Title").ParseToDocument()); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+
+	want := "<h1 id=\"title\">Title</h1>\n<h2 id=\"title-1\">Title</h2>\n<blockquote>\n<h2 id=\"quoted-title\"><em>Quoted</em> title</h2>\n</blockquote>\n" +
+		"<ul>\n<li>\n<h1 id=\"in-list\">In list</h1>\n<h1 id=\"title-2\">Title</h1>\n</li>\n</ul>\n"
+	if got := b.String(); got != want {
+		t.Errorf("HTMLRenderer.Render() = %q, want %q", got, want)
+	}
+}
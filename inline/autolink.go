@@ -0,0 +1,157 @@
+package inline
+
+import "strings"
+
+// autolinkBoundary reports whether an extended autolink may start at pos,
+// which is at the beginning of the input, after whitespace or after one of `*`, `_`, `~` and `(`.
+// Autolinks are not recognised inside the text of a link that may still be closed, since links may not contain other links,
+// nor when the parser follows CommonMark only.
+func (s *state) autolinkBoundary(pos int) bool {
+	if s.commonMark || s.inLinkText() {
+		return false
+	}
+
+	if pos == 0 {
+		return true
+	}
+
+	before := s.input[pos-1]
+
+	return isWhitespace(before) || strings.ContainsRune("*_~(", before)
+}
+
+// parseExtendedAutolink turns a bare `www.` domain, `http://` or `https://` URL or email address at the position into an autolink
+func (s *state) parseExtendedAutolink() bool {
+	tk, end, ok := s.extendedAutolink(s.pos)
+	if !ok {
+		return false
+	}
+
+	s.nodes.append(&node{token: tk})
+	s.pos = end
+
+	return true
+}
+
+// extendedAutolink returns the autolink starting at pos and its end following the GFM autolink extension
+func (s *state) extendedAutolink(pos int) (Token, int, bool) {
+	rest := string(s.input[pos:min(pos+8, len(s.input))])
+
+	var domainStart int
+	var scheme string
+	switch {
+	case strings.HasPrefix(rest, "www."):
+		domainStart, scheme = pos, "http://"
+	case strings.HasPrefix(rest, "http://"):
+		domainStart = pos + len("http://")
+	case strings.HasPrefix(rest, "https://"):
+		domainStart = pos + len("https://")
+	default:
+		return s.emailAutolink(pos)
+	}
+
+	// a URL with a scheme may have a domain without a period such as localhost
+	domainEnd, ok := s.validDomain(domainStart, scheme == "")
+	if !ok {
+		return nil, 0, false
+	}
+
+	end := domainEnd
+	for end < len(s.input) && !isWhitespace(s.input[end]) && s.input[end] != '<' {
+		end++
+	}
+
+	end = s.trimAutolinkTrailing(pos, domainEnd, end)
+	text := string(s.input[pos:end])
+
+	return NewAutolink(scheme+text, text), end, true
+}
+
+// validDomain returns the end of the domain starting at start.
+// A domain is segments of alphanumerics, `_` and `-` separated by periods, with no `_` in its last two segments.
+func (s *state) validDomain(start int, allowShort bool) (int, bool) {
+	end := start
+	for end < len(s.input) && (isAlphanumeric(s.input[end]) || strings.ContainsRune("._-", s.input[end])) {
+		end++
+	}
+
+	segments := strings.Split(strings.TrimRight(string(s.input[start:end]), "."), ".")
+	if segments[0] == "" || (len(segments) < 2 && !allowShort) {
+		return 0, false
+	}
+
+	for i, segment := range segments {
+		if segment == "" || (i >= len(segments)-2 && strings.Contains(segment, "_")) {
+			return 0, false
+		}
+	}
+
+	return end, true
+}
+
+// trimAutolinkTrailing excludes the trailing punctuation from the autolink ending at end.
+// An unbalanced `)` and an entity reference such as `&amp;` at the end are excluded as well.
+func (s *state) trimAutolinkTrailing(start int, domainEnd int, end int) int {
+	for end > domainEnd {
+		last := s.input[end-1]
+
+		switch {
+		case strings.ContainsRune("?!.,:*_~", last):
+			end--
+		case last == ')':
+			link := string(s.input[start:end])
+			if strings.Count(link, ")") <= strings.Count(link, "(") {
+				return end
+			}
+			end--
+		case last == ';':
+			entity := end - 2
+			for entity > domainEnd && isAlphanumeric(s.input[entity]) {
+				entity--
+			}
+			if entity == end-2 || s.input[entity] != '&' {
+				return end
+			}
+			end = entity
+		default:
+			return end
+		}
+	}
+
+	return end
+}
+
+// emailAutolink recognizes an email address such as `foo@bar.example`
+func (s *state) emailAutolink(pos int) (Token, int, bool) {
+	at := pos
+	for at < len(s.input) && (isAlphanumeric(s.input[at]) || strings.ContainsRune(".-_+", s.input[at])) {
+		at++
+	}
+
+	if at == pos || at >= len(s.input) || s.input[at] != '@' {
+		return nil, 0, false
+	}
+
+	end := at + 1
+	for end < len(s.input) && (isAlphanumeric(s.input[end]) || strings.ContainsRune("._-", s.input[end])) {
+		end++
+	}
+
+	// a trailing period ends the sentence rather than the address
+	for end > at+1 && s.input[end-1] == '.' {
+		end--
+	}
+
+	domain := string(s.input[at+1 : end])
+	if !strings.Contains(domain, ".") || strings.HasSuffix(domain, "-") || strings.HasSuffix(domain, "_") || strings.Contains(domain, "..") {
+		return nil, 0, false
+	}
+
+	text := string(s.input[pos:end])
+
+	return NewAutolink("mailto:"+text, text), end, true
+}
+
+func isAlphanumeric(char rune) bool {
+	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || ('0' <= char && char <= '9')
+}
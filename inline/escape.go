@@ -0,0 +1,87 @@
+package inline
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// entityPattern matches a named entity, a decimal and a hexadecimal numeric character reference
+var entityPattern = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[A-Za-z][A-Za-z0-9]{0,31});`)
+
+// decodeEntity returns the character of the entity reference starting at pos and the length of the reference.
+// A named entity must be one of the HTML5 entities, and an invalid code point is replaced with U+FFFD.
+func decodeEntity(input []rune, pos int) (string, int, bool) {
+	reference := entityPattern.FindString(string(input[pos:min(pos+40, len(input))]))
+	if reference == "" {
+		return "", 0, false
+	}
+
+	name := reference[1 : len(reference)-1]
+	if strings.HasPrefix(name, "#") {
+		var code int64
+		if name[1] == 'x' || name[1] == 'X' {
+			code, _ = strconv.ParseInt(name[2:], 16, 32)
+		} else {
+			code, _ = strconv.ParseInt(name[1:], 10, 32)
+		}
+
+		char := rune(code)
+		if char == 0 || !utf8.ValidRune(char) {
+			char = unicode.ReplacementChar
+		}
+
+		return string(char), len(reference), true
+	}
+
+	// html.UnescapeString also decodes a legacy entity without the semicolon such as `&not` in `&notit;`,
+	// which is not an entity reference in Markdown
+	decoded := html.UnescapeString(reference)
+	if decoded == reference || decoded == html.UnescapeString(reference[:len(reference)-1])+";" {
+		return "", 0, false
+	}
+
+	return decoded, len(reference), true
+}
+
+// parseEntity turns an entity reference into the character it stands for, keeping the reference as the source
+func (s *state) parseEntity() {
+	if decoded, length, ok := decodeEntity(s.input, s.pos); ok {
+		s.nodes.append(&node{text: decoded, source: string(s.input[s.pos : s.pos+length])})
+		s.pos += length
+		return
+	}
+
+	s.pos++
+	s.appendText("&")
+}
+
+// Unescape resolves the backslash escapes and entity references in the text,
+// which is how link destinations, link titles and code block info strings are read
+func Unescape(text string) string {
+	return unescape([]rune(text))
+}
+
+// unescape removes backslashes before ASCII punctuation characters and decodes entity references
+func unescape(input []rune) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(input); i++ {
+		switch {
+		case input[i] == '\\' && i+1 < len(input) && isASCIIPunctuation(input[i+1]):
+			i++
+		case input[i] == '&':
+			if decoded, length, ok := decodeEntity(input, i); ok {
+				builder.WriteString(decoded)
+				i += length - 1
+				continue
+			}
+		}
+		builder.WriteRune(input[i])
+	}
+
+	return builder.String()
+}
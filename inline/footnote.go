@@ -0,0 +1,36 @@
+package inline
+
+// Footnotes is the set of the normalized labels of the footnote definitions in a document
+type Footnotes map[string]struct{}
+
+func (f Footnotes) Add(label string) {
+	if key := NormalizeLabel(label); key != "" {
+		f[key] = struct{}{}
+	}
+}
+
+func (f Footnotes) Has(label string) bool {
+	_, ok := f[NormalizeLabel(label)]
+
+	return ok
+}
+
+// parseFootnoteReference returns the label and the length of the footnote reference `[^label]` starting at pos.
+// The label has to be defined, otherwise the brackets are parsed as ordinary text or a link.
+func (s *state) parseFootnoteReference() (string, int, bool) {
+	if s.footnotes == nil || s.pos+1 >= len(s.input) || s.input[s.pos+1] != '^' {
+		return "", 0, false
+	}
+
+	length := parseLinkLabel(s.input, s.pos)
+	if length <= 3 {
+		return "", 0, false
+	}
+
+	label := string(s.input[s.pos+2 : s.pos+length-1])
+	if !s.footnotes.Has(label) {
+		return "", 0, false
+	}
+
+	return label, length, true
+}
@@ -0,0 +1,288 @@
+package inline
+
+import (
+	"regexp"
+	"strings"
+)
+
+type bracket struct {
+	node  *node
+	index int
+	image bool
+	// bracketAfter is set when another bracket is opened inside this one
+	bracketAfter  bool
+	active        bool
+	prevDelimiter *delimiter
+	prev          *bracket
+}
+
+func (s *state) pushBracket(n *node, image bool) {
+	if s.brackets != nil {
+		s.brackets.bracketAfter = true
+	}
+
+	s.brackets = &bracket{
+		node:          n,
+		index:         s.pos,
+		image:         image,
+		active:        true,
+		prevDelimiter: s.delimiters,
+		prev:          s.brackets,
+	}
+}
+
+func (s *state) parseOpenBracket() {
+	if label, length, ok := s.parseFootnoteReference(); ok {
+		s.pos += length
+		s.nodes.append(&node{token: NewFootnoteReference(label)})
+		return
+	}
+
+	s.pos++
+	s.pushBracket(s.appendText("["), false)
+}
+
+func (s *state) parseBang() {
+	if s.pos+1 < len(s.input) && s.input[s.pos+1] == '[' {
+		s.pos += 2
+		s.pushBracket(s.appendText("!["), true)
+		return
+	}
+
+	s.pos++
+	s.appendText("!")
+}
+
+func (s *state) parseCloseBracket() {
+	textEnd := s.pos
+	s.pos++
+
+	opener := s.brackets
+	if opener == nil {
+		s.appendText("]")
+		return
+	}
+
+	if !opener.active {
+		s.brackets = opener.prev
+		s.appendText("]")
+		return
+	}
+
+	destination, title, end, ok := parseInlineLinkTail(s.input, s.pos)
+	if !ok {
+		destination, title, end, ok = s.parseReferenceLinkTail(opener, textEnd)
+	}
+
+	if !ok {
+		s.brackets = opener.prev
+		s.appendText("]")
+		return
+	}
+
+	s.pos = end
+
+	// emphasis inside the link text is resolved before the text is wrapped
+	s.processEmphasis(opener.prevDelimiter)
+	children := s.nodes.extract(opener.node.next, nil)
+	s.nodes.remove(opener.node)
+	s.brackets = opener.prev
+
+	if opener.image {
+		s.nodes.append(&node{token: NewImage(destination, title, children)})
+		return
+	}
+
+	s.nodes.append(&node{token: NewLink(destination, title, children)})
+
+	// links may not contain other links
+	for b := s.brackets; b != nil; b = b.prev {
+		if !b.image {
+			b.active = false
+		}
+	}
+}
+
+// inLinkText reports whether an active `[` or `![` opener is waiting for its closing bracket
+func (s *state) inLinkText() bool {
+	for b := s.brackets; b != nil; b = b.prev {
+		if b.active {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseReferenceLinkTail resolves full `[text][label]`, collapsed `[text][]` and shortcut `[text]` references
+func (s *state) parseReferenceLinkTail(opener *bracket, textEnd int) (string, string, int, bool) {
+	if s.references == nil {
+		return "", "", 0, false
+	}
+
+	end := s.pos
+	label := ""
+
+	labelLength := parseLinkLabel(s.input, s.pos)
+	if labelLength > 2 {
+		label = string(s.input[s.pos+1 : s.pos+labelLength-1])
+		end += labelLength
+	} else if !opener.bracketAfter {
+		label = string(s.input[opener.index:textEnd])
+		end += labelLength
+	}
+
+	if label == "" {
+		return "", "", 0, false
+	}
+
+	reference, ok := s.references.Lookup(label)
+	if !ok {
+		return "", "", 0, false
+	}
+
+	return reference.Destination(), reference.Title(), end, true
+}
+
+// parseInlineLinkTail parses `(destination "title")` starting at pos
+func parseInlineLinkTail(input []rune, pos int) (string, string, int, bool) {
+	if pos >= len(input) || input[pos] != '(' {
+		return "", "", 0, false
+	}
+
+	pos = skipSpaces(input, pos+1)
+
+	destination := ""
+	if pos < len(input) && input[pos] != ')' {
+		dest, end, ok := parseLinkDestination(input, pos)
+		if !ok {
+			return "", "", 0, false
+		}
+		destination = dest
+		pos = end
+	}
+
+	title := ""
+	afterSpaces := skipSpaces(input, pos)
+	if afterSpaces > pos && afterSpaces < len(input) && input[afterSpaces] != ')' {
+		t, end, ok := parseLinkTitle(input, afterSpaces)
+		if !ok {
+			return "", "", 0, false
+		}
+		title = t
+		afterSpaces = skipSpaces(input, end)
+	}
+	pos = afterSpaces
+
+	if pos >= len(input) || input[pos] != ')' {
+		return "", "", 0, false
+	}
+
+	return destination, title, pos + 1, true
+}
+
+func skipSpaces(input []rune, pos int) int {
+	for pos < len(input) && (input[pos] == ' ' || input[pos] == '\t' || input[pos] == '\n') {
+		pos++
+	}
+
+	return pos
+}
+
+func isASCIIPunctuation(char rune) bool {
+	return char < 0x80 && strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", char)
+}
+
+func parseLinkDestination(input []rune, pos int) (string, int, bool) {
+	if input[pos] == '<' {
+		for idx := pos + 1; idx < len(input); idx++ {
+			switch input[idx] {
+			case '\\':
+				idx++
+			case '\n', '<':
+				return "", 0, false
+			case '>':
+				return unescape(input[pos+1 : idx]), idx + 1, true
+			}
+		}
+
+		return "", 0, false
+	}
+
+	depth := 0
+	idx := pos
+loop:
+	for ; idx < len(input); idx++ {
+		char := input[idx]
+
+		switch {
+		case char == '\\' && idx+1 < len(input) && isASCIIPunctuation(input[idx+1]):
+			idx++
+		case char == '(':
+			depth++
+		case char == ')':
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		case char <= ' ' || char == 0x7f:
+			break loop
+		}
+	}
+
+	if idx == pos || depth != 0 {
+		return "", 0, false
+	}
+
+	return unescape(input[pos:idx]), idx, true
+}
+
+func parseLinkTitle(input []rune, pos int) (string, int, bool) {
+	closing := input[pos]
+	switch closing {
+	case '"', '\'':
+	case '(':
+		closing = ')'
+	default:
+		return "", 0, false
+	}
+
+	for idx := pos + 1; idx < len(input); idx++ {
+		switch input[idx] {
+		case '\\':
+			idx++
+		case closing:
+			return unescape(input[pos+1 : idx]), idx + 1, true
+		case '(':
+			if closing == ')' {
+				return "", 0, false
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
+var (
+	uriAutolinkPattern   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*)>`)
+	emailAutolinkPattern = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+)
+
+func (s *state) parseAutolink() {
+	rest := string(s.input[s.pos:])
+
+	if m := uriAutolinkPattern.FindStringSubmatch(rest); m != nil {
+		s.nodes.append(&node{token: NewAutolink(m[1], m[1])})
+		s.pos += len([]rune(m[0]))
+		return
+	}
+
+	if m := emailAutolinkPattern.FindStringSubmatch(rest); m != nil {
+		s.nodes.append(&node{token: NewAutolink("mailto:"+m[1], m[1])})
+		s.pos += len([]rune(m[0]))
+		return
+	}
+
+	s.pos++
+	s.appendText("<")
+}
@@ -0,0 +1,532 @@
+package inline
+
+import (
+	"strings"
+	"unicode"
+)
+
+type node struct {
+	// text is used when token is nil
+	text string
+	// source is the text as written when it differs from text, such as `\*` or `&amp;`
+	source string
+	token  Token
+	prev   *node
+	next   *node
+}
+
+type nodeList struct {
+	head *node
+	tail *node
+	// keepRaw makes the Text tokens hold their source text
+	keepRaw bool
+}
+
+func (l *nodeList) append(n *node) {
+	n.prev = l.tail
+	n.next = nil
+
+	if l.tail == nil {
+		l.head = n
+	} else {
+		l.tail.next = n
+	}
+	l.tail = n
+}
+
+func (l *nodeList) insertAfter(target *node, n *node) {
+	n.prev = target
+	n.next = target.next
+
+	if target.next == nil {
+		l.tail = n
+	} else {
+		target.next.prev = n
+	}
+	target.next = n
+}
+
+func (l *nodeList) remove(n *node) {
+	if n.prev == nil {
+		l.head = n.next
+	} else {
+		n.prev.next = n.next
+	}
+
+	if n.next == nil {
+		l.tail = n.prev
+	} else {
+		n.next.prev = n.prev
+	}
+
+	n.prev = nil
+	n.next = nil
+}
+
+// extract detaches the nodes from `from` up to (but not including) `to` and returns them as tokens
+func (l *nodeList) extract(from *node, to *node) []Token {
+	extracted := nodeList{keepRaw: l.keepRaw}
+
+	for n := from; n != nil && n != to; {
+		next := n.next
+		l.remove(n)
+		extracted.append(n)
+		n = next
+	}
+
+	return extracted.tokens()
+}
+
+func (l *nodeList) tokens() []Token {
+	tokens := make([]Token, 0)
+	var text strings.Builder
+	var raw strings.Builder
+	hasText := false
+
+	flush := func() {
+		if hasText {
+			tk := NewText(text.String())
+			if l.keepRaw {
+				tk = tk.WithRaw(raw.String())
+			}
+			tokens = append(tokens, tk)
+			text.Reset()
+			raw.Reset()
+			hasText = false
+		}
+	}
+
+	for n := l.head; n != nil; n = n.next {
+		if n.token == nil {
+			if n.text == "" {
+				continue
+			}
+
+			text.WriteString(n.text)
+			if n.source != "" {
+				raw.WriteString(n.source)
+			} else {
+				raw.WriteString(n.text)
+			}
+			hasText = true
+			continue
+		}
+
+		flush()
+		tokens = append(tokens, n.token)
+	}
+	flush()
+
+	return tokens
+}
+
+type delimiter struct {
+	char      rune
+	count     int
+	origCount int
+	canOpen   bool
+	canClose  bool
+	node      *node
+	prev      *delimiter
+	next      *delimiter
+}
+
+type openerKey struct {
+	char     rune
+	canOpen  bool
+	modThree int
+}
+
+type state struct {
+	input      []rune
+	pos        int
+	nodes      nodeList
+	delimiters *delimiter
+	brackets   *bracket
+	references References
+	footnotes  Footnotes
+	// commonMark disables the GFM extended autolinks and strikethrough
+	commonMark bool
+}
+
+type Parser struct {
+	references References
+	footnotes  Footnotes
+	keepRaw    bool
+	commonMark bool
+}
+
+// NewParser returns a Parser that resolves reference links against the given definitions
+func NewParser(references References) *Parser {
+	return &Parser{
+		references: references,
+	}
+}
+
+// WithFootnotes returns a copy of the parser which turns `[^label]` into a footnote reference when the label is defined
+func (p Parser) WithFootnotes(footnotes Footnotes) *Parser {
+	p.footnotes = footnotes
+	return &p
+}
+
+// WithRawText returns a copy of the parser whose Text tokens also hold the source text,
+// in which the backslash escapes and entity references are kept as written
+func (p Parser) WithRawText() *Parser {
+	p.keepRaw = true
+	return &p
+}
+
+// WithCommonMark returns a copy of the parser which follows CommonMark only,
+// leaving bare URLs and tildes as text instead of parsing the GFM extended autolinks and strikethrough
+func (p Parser) WithCommonMark() *Parser {
+	p.commonMark = true
+	return &p
+}
+
+func Parse(input string) []Token {
+	return NewParser(nil).Parse(input)
+}
+
+func (p *Parser) Parse(input string) []Token {
+	s := &state{
+		input:      []rune(input),
+		nodes:      nodeList{keepRaw: p.keepRaw},
+		references: p.references,
+		footnotes:  p.footnotes,
+		commonMark: p.commonMark,
+	}
+
+	for s.pos < len(s.input) {
+		if s.autolinkBoundary(s.pos) && s.parseExtendedAutolink() {
+			continue
+		}
+
+		switch s.input[s.pos] {
+		case '`':
+			s.parseBackticks()
+		case '*', '_':
+			s.parseDelimiterRun()
+		case '~':
+			if s.commonMark {
+				s.parseText()
+			} else {
+				s.parseDelimiterRun()
+			}
+		case '[':
+			s.parseOpenBracket()
+		case '!':
+			s.parseBang()
+		case ']':
+			s.parseCloseBracket()
+		case '<':
+			s.parseAutolink()
+		case '\n':
+			s.parseLineEnding()
+		case '\\':
+			s.parseBackslash()
+		case '&':
+			s.parseEntity()
+		default:
+			s.parseText()
+		}
+	}
+
+	s.processEmphasis(nil)
+
+	return s.nodes.tokens()
+}
+
+func isSpecial(char rune) bool {
+	switch char {
+	case '`', '*', '_', '~', '[', ']', '!', '<', '\n', '\\', '&':
+		return true
+	}
+
+	return false
+}
+
+func (s *state) appendText(text string) *node {
+	n := &node{text: text}
+	s.nodes.append(n)
+
+	return n
+}
+
+func (s *state) parseText() {
+	start := s.pos
+	s.pos++
+
+	for s.pos < len(s.input) && !isSpecial(s.input[s.pos]) {
+		// the text stops before a bare URL, which is parsed as an autolink
+		if s.autolinkBoundary(s.pos) {
+			if _, _, ok := s.extendedAutolink(s.pos); ok {
+				break
+			}
+		}
+		s.pos++
+	}
+
+	s.appendText(string(s.input[start:s.pos]))
+}
+
+// parseLineEnding turns the line ending into a hard break when it follows two or more spaces, and a soft break otherwise.
+// The spaces around the line ending are dropped.
+func (s *state) parseLineEnding() {
+	var tk Token = NewSoftBreak()
+
+	if tail := s.nodes.tail; tail != nil && tail.token == nil {
+		trimmed := strings.TrimRight(tail.text, " ")
+		if len(tail.text)-len(trimmed) >= 2 {
+			tk = NewHardBreak()
+		}
+		tail.text = trimmed
+	}
+
+	s.nodes.append(&node{token: tk})
+	s.pos++
+	s.skipLineIndent()
+}
+
+// parseBackslash turns a backslash at the end of a line into a hard break,
+// and a backslash before an ASCII punctuation character into the literal character
+func (s *state) parseBackslash() {
+	if s.pos+1 < len(s.input) && s.input[s.pos+1] == '\n' {
+		s.nodes.append(&node{token: NewHardBreak()})
+		s.pos += 2
+		s.skipLineIndent()
+		return
+	}
+
+	if s.pos+1 < len(s.input) && isASCIIPunctuation(s.input[s.pos+1]) {
+		s.nodes.append(&node{text: string(s.input[s.pos+1]), source: string(s.input[s.pos : s.pos+2])})
+		s.pos += 2
+		return
+	}
+
+	s.appendText("\\")
+	s.pos++
+}
+
+func (s *state) skipLineIndent() {
+	for s.pos < len(s.input) && (s.input[s.pos] == ' ' || s.input[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+func (s *state) runLength(char rune) int {
+	end := s.pos
+	for end < len(s.input) && s.input[end] == char {
+		end++
+	}
+
+	return end - s.pos
+}
+
+func (s *state) parseBackticks() {
+	length := s.runLength('`')
+	contentStart := s.pos + length
+
+	for idx := contentStart; idx < len(s.input); {
+		if s.input[idx] != '`' {
+			idx++
+			continue
+		}
+
+		closeStart := idx
+		for idx < len(s.input) && s.input[idx] == '`' {
+			idx++
+		}
+
+		if idx-closeStart == length {
+			s.nodes.append(&node{token: NewCodeSpan(normalizeCodeSpan(s.input[contentStart:closeStart]))})
+			s.pos = idx
+			return
+		}
+	}
+
+	// no matching closer: the backtick string is literal text
+	s.appendText(string(s.input[s.pos:contentStart]))
+	s.pos = contentStart
+}
+
+func normalizeCodeSpan(content []rune) string {
+	code := strings.ReplaceAll(string(content), "\n", " ")
+
+	if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+		code = code[1 : len(code)-1]
+	}
+
+	return code
+}
+
+func isWhitespace(char rune) bool {
+	return unicode.IsSpace(char)
+}
+
+func isPunctuation(char rune) bool {
+	return unicode.IsPunct(char) || unicode.IsSymbol(char)
+}
+
+// flanking reports whether the delimiter run between start and end is left- or right-flanking
+func (s *state) flanking(start int, end int) (bool, bool, rune, rune) {
+	before := '\n'
+	if start > 0 {
+		before = s.input[start-1]
+	}
+
+	after := '\n'
+	if end < len(s.input) {
+		after = s.input[end]
+	}
+
+	leftFlanking := !isWhitespace(after) &&
+		(!isPunctuation(after) || isWhitespace(before) || isPunctuation(before))
+	rightFlanking := !isWhitespace(before) &&
+		(!isPunctuation(before) || isWhitespace(after) || isPunctuation(after))
+
+	return leftFlanking, rightFlanking, before, after
+}
+
+func (s *state) parseDelimiterRun() {
+	char := s.input[s.pos]
+	length := s.runLength(char)
+	start := s.pos
+	s.pos += length
+
+	leftFlanking, rightFlanking, before, after := s.flanking(start, s.pos)
+
+	canOpen := leftFlanking
+	canClose := rightFlanking
+	if char == '_' {
+		canOpen = leftFlanking && (!rightFlanking || isPunctuation(before))
+		canClose = rightFlanking && (!leftFlanking || isPunctuation(after))
+	}
+
+	n := s.appendText(string(s.input[start:s.pos]))
+
+	// strikethrough is one or two tildes, a longer run is literal text
+	if char == '~' && length > 2 {
+		return
+	}
+
+	s.pushDelimiter(&delimiter{
+		char:      char,
+		count:     length,
+		origCount: length,
+		canOpen:   canOpen,
+		canClose:  canClose,
+		node:      n,
+	})
+}
+
+func (s *state) pushDelimiter(d *delimiter) {
+	d.prev = s.delimiters
+	if s.delimiters != nil {
+		s.delimiters.next = d
+	}
+	s.delimiters = d
+}
+
+func (s *state) removeDelimiter(d *delimiter) {
+	if d.prev != nil {
+		d.prev.next = d.next
+	}
+
+	if d.next == nil {
+		s.delimiters = d.prev
+	} else {
+		d.next.prev = d.prev
+	}
+}
+
+// processEmphasis resolves the delimiter runs above stackBottom into Emphasis, Strong and Strikethrough tokens
+func (s *state) processEmphasis(stackBottom *delimiter) {
+	openersBottom := make(map[openerKey]*delimiter)
+
+	closer := s.delimiters
+	for closer != nil && closer.prev != stackBottom {
+		closer = closer.prev
+	}
+
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+
+		key := openerKey{closer.char, closer.canOpen, closer.origCount % 3}
+
+		opener := closer.prev
+		found := false
+		for opener != nil && opener != stackBottom && opener != openersBottom[key] {
+			oddMatch := (closer.canOpen || opener.canClose) &&
+				closer.origCount%3 != 0 &&
+				(opener.origCount+closer.origCount)%3 == 0
+
+			// tildes only match a run of the same length, to which the rule of three does not apply
+			if closer.char == '~' {
+				oddMatch = opener.origCount != closer.origCount
+			}
+
+			if opener.char == closer.char && opener.canOpen && !oddMatch {
+				found = true
+				break
+			}
+
+			opener = opener.prev
+		}
+
+		if !found {
+			openersBottom[key] = closer.prev
+
+			next := closer.next
+			if !closer.canOpen {
+				s.removeDelimiter(closer)
+			}
+			closer = next
+			continue
+		}
+
+		use := 1
+		if closer.char == '~' {
+			use = closer.count
+		} else if opener.count >= 2 && closer.count >= 2 {
+			use = 2
+		}
+
+		opener.count -= use
+		closer.count -= use
+		opener.node.text = opener.node.text[:opener.count]
+		closer.node.text = closer.node.text[:closer.count]
+
+		children := s.nodes.extract(opener.node.next, closer.node)
+
+		var wrapped Token = NewEmphasis(children)
+		if closer.char == '~' {
+			wrapped = NewStrikethrough(children)
+		} else if use == 2 {
+			wrapped = NewStrong(children)
+		}
+		s.nodes.insertAfter(opener.node, &node{token: wrapped})
+
+		// delimiters between the opener and the closer can no longer match
+		opener.next = closer
+		closer.prev = opener
+
+		if opener.count == 0 {
+			s.nodes.remove(opener.node)
+			s.removeDelimiter(opener)
+		}
+
+		if closer.count == 0 {
+			next := closer.next
+			s.nodes.remove(closer.node)
+			s.removeDelimiter(closer)
+			closer = next
+		}
+	}
+
+	for s.delimiters != nil && s.delimiters != stackBottom {
+		s.removeDelimiter(s.delimiters)
+	}
+}
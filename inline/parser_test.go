@@ -0,0 +1,665 @@
+package inline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "Text",
+			input: "plain text",
+			want: []Token{
+				NewText("plain text"),
+			},
+		},
+		{
+			name:  "Soft break",
+			input: "first \n  second",
+			want: []Token{
+				NewText("first"),
+				NewSoftBreak(),
+				NewText("second"),
+			},
+		},
+		{
+			name:  "Hard break by spaces",
+			input: "first   \nsecond",
+			want: []Token{
+				NewText("first"),
+				NewHardBreak(),
+				NewText("second"),
+			},
+		},
+		{
+			name:  "Hard break by backslash",
+			input: "*first*\\\nsecond",
+			want: []Token{
+				NewEmphasis([]Token{NewText("first")}),
+				NewHardBreak(),
+				NewText("second"),
+			},
+		},
+		{
+			name:  "Backslash at the end",
+			input: "text\\",
+			want: []Token{
+				NewText("text\\"),
+			},
+		},
+		{
+			name:  "Emphasis by *",
+			input: "*em*",
+			want: []Token{
+				NewEmphasis([]Token{NewText("em")}),
+			},
+		},
+		{
+			name:  "Emphasis by _",
+			input: "_em_",
+			want: []Token{
+				NewEmphasis([]Token{NewText("em")}),
+			},
+		},
+		{
+			name:  "Strong",
+			input: "a **strong** b",
+			want: []Token{
+				NewText("a "),
+				NewStrong([]Token{NewText("strong")}),
+				NewText(" b"),
+			},
+		},
+		{
+			name:  "Strong inside Emphasis",
+			input: "*a **b** c*",
+			want: []Token{
+				NewEmphasis([]Token{
+					NewText("a "),
+					NewStrong([]Token{NewText("b")}),
+					NewText(" c"),
+				}),
+			},
+		},
+		{
+			name:  "Triple delimiter makes Emphasis wrapping Strong",
+			input: "***both***",
+			want: []Token{
+				NewEmphasis([]Token{
+					NewStrong([]Token{NewText("both")}),
+				}),
+			},
+		},
+		{
+			name:  "Unmatched opener is text",
+			input: "**foo*",
+			want: []Token{
+				NewText("*"),
+				NewEmphasis([]Token{NewText("foo")}),
+			},
+		},
+		{
+			name:  "Delimiter followed by whitespace is not left-flanking",
+			input: "a * foo bar*",
+			want: []Token{
+				NewText("a * foo bar*"),
+			},
+		},
+		{
+			name:  "Intraword _ is not emphasis",
+			input: "snake_case_name",
+			want: []Token{
+				NewText("snake_case_name"),
+			},
+		},
+		{
+			name:  "Intraword * is emphasis",
+			input: "foo*bar*",
+			want: []Token{
+				NewText("foo"),
+				NewEmphasis([]Token{NewText("bar")}),
+			},
+		},
+		{
+			name:  "Rule of three",
+			input: "*foo**bar**baz*",
+			want: []Token{
+				NewEmphasis([]Token{
+					NewText("foo"),
+					NewStrong([]Token{NewText("bar")}),
+					NewText("baz"),
+				}),
+			},
+		},
+		{
+			name:  "CodeSpan",
+			input: "use `go test`",
+			want: []Token{
+				NewText("use "),
+				NewCodeSpan("go test"),
+			},
+		},
+		{
+			name:  "CodeSpan strips a single surrounding space",
+			input: "`` `code` ``",
+			want: []Token{
+				NewCodeSpan("`code`"),
+			},
+		},
+		{
+			name:  "CodeSpan takes precedence over Emphasis",
+			input: "*a `*` b*",
+			want: []Token{
+				NewEmphasis([]Token{
+					NewText("a "),
+					NewCodeSpan("*"),
+					NewText(" b"),
+				}),
+			},
+		},
+		{
+			name:  "Unmatched backticks are text",
+			input: "```foo``",
+			want: []Token{
+				NewText("```foo``"),
+			},
+		},
+		{
+			name:  "Link",
+			input: "see [docs](https://example.com)",
+			want: []Token{
+				NewText("see "),
+				NewLink("https://example.com", "", []Token{NewText("docs")}),
+			},
+		},
+		{
+			name:  "Link with title",
+			input: `[docs](/url "the title")`,
+			want: []Token{
+				NewLink("/url", "the title", []Token{NewText("docs")}),
+			},
+		},
+		{
+			name:  "Link with pointy destination",
+			input: "[a](<my url> 'title')",
+			want: []Token{
+				NewLink("my url", "title", []Token{NewText("a")}),
+			},
+		},
+		{
+			name:  "Link destination with balanced parentheses",
+			input: "[a](foo(and(bar)))",
+			want: []Token{
+				NewLink("foo(and(bar))", "", []Token{NewText("a")}),
+			},
+		},
+		{
+			name:  "Link with emphasis in text",
+			input: "[*em* text](/url)",
+			want: []Token{
+				NewLink("/url", "", []Token{
+					NewEmphasis([]Token{NewText("em")}),
+					NewText(" text"),
+				}),
+			},
+		},
+		{
+			name:  "Links may not contain links",
+			input: "[foo [bar](/uri)](/uri2)",
+			want: []Token{
+				NewText("[foo "),
+				NewLink("/uri", "", []Token{NewText("bar")}),
+				NewText("](/uri2)"),
+			},
+		},
+		{
+			name:  "Emphasis does not span the link boundary",
+			input: "*[foo*](/url)",
+			want: []Token{
+				NewText("*"),
+				NewLink("/url", "", []Token{NewText("foo*")}),
+			},
+		},
+		{
+			name:  "Brackets without destination are text",
+			input: "[not a link]",
+			want: []Token{
+				NewText("[not a link]"),
+			},
+		},
+		{
+			name:  "Image",
+			input: `![alt *text*](/img.png "title")`,
+			want: []Token{
+				NewImage("/img.png", "title", []Token{
+					NewText("alt "),
+					NewEmphasis([]Token{NewText("text")}),
+				}),
+			},
+		},
+		{
+			name:  "Bang without bracket is text",
+			input: "Hello!",
+			want: []Token{
+				NewText("Hello!"),
+			},
+		},
+		{
+			name:  "URI Autolink",
+			input: "<https://example.com/a?b=c>",
+			want: []Token{
+				NewAutolink("https://example.com/a?b=c", "https://example.com/a?b=c"),
+			},
+		},
+		{
+			name:  "Email Autolink",
+			input: "<foo@bar.example.com>",
+			want: []Token{
+				NewAutolink("mailto:foo@bar.example.com", "foo@bar.example.com"),
+			},
+		},
+		{
+			name:  "Autolink may not contain spaces",
+			input: "<https://foo.bar/baz bim>",
+			want: []Token{
+				NewText("<https://foo.bar/baz bim>"),
+			},
+		},
+		{
+			name:  "Backslash escapes",
+			input: "\\*not emphasized\\* \\[not a link] \\a",
+			want: []Token{
+				NewText("*not emphasized* [not a link] \\a"),
+			},
+		},
+		{
+			name:  "Entity references",
+			input: "&amp; &copy; &#35; &#x22; &#0; &nosuch; &copy",
+			want: []Token{
+				NewText("& © # \" \uFFFD &nosuch; &copy"),
+			},
+		},
+		{
+			name:  "Entity references are not decoded in code spans",
+			input: "`&amp;` &ast;a*",
+			want: []Token{
+				NewCodeSpan("&amp;"),
+				NewText(" *a*"),
+			},
+		},
+		{
+			name:  "Escapes and entities in link destination and title",
+			input: `[a](/f&ouml;\*o "t&auml;\"")`,
+			want: []Token{
+				NewLink("/fö*o", "tä\"", []Token{NewText("a")}),
+			},
+		},
+		{
+			name:  "Strikethrough",
+			input: "~~deleted~~ and ~one~",
+			want: []Token{
+				NewStrikethrough([]Token{NewText("deleted")}),
+				NewText(" and "),
+				NewStrikethrough([]Token{NewText("one")}),
+			},
+		},
+		{
+			name:  "Strikethrough needs runs of the same length",
+			input: "~~a~ ~~~b~~~",
+			want: []Token{
+				NewText("~~a~ ~~~b~~~"),
+			},
+		},
+		{
+			name:  "Strikethrough with emphasis",
+			input: "~~*a*~~",
+			want: []Token{
+				NewStrikethrough([]Token{NewEmphasis([]Token{NewText("a")})}),
+			},
+		},
+		{
+			name:  "Extended www autolink",
+			input: "Visit www.commonmark.org/help.",
+			want: []Token{
+				NewText("Visit "),
+				NewAutolink("http://www.commonmark.org/help", "www.commonmark.org/help"),
+				NewText("."),
+			},
+		},
+		{
+			name:  "Extended URL autolink with balanced parentheses",
+			input: "(https://en.wikipedia.org/wiki/Go_(language))",
+			want: []Token{
+				NewText("("),
+				NewAutolink("https://en.wikipedia.org/wiki/Go_(language)", "https://en.wikipedia.org/wiki/Go_(language)"),
+				NewText(")"),
+			},
+		},
+		{
+			name:  "Extended autolink excludes a trailing entity",
+			input: "www.google.com/search?q=commonmark&hl;",
+			want: []Token{
+				NewAutolink("http://www.google.com/search?q=commonmark", "www.google.com/search?q=commonmark"),
+				NewText("&hl;"),
+			},
+		},
+		{
+			name:  "Extended autolink with underscore in the last segments",
+			input: "www.a_b.example",
+			want: []Token{
+				NewText("www.a_b.example"),
+			},
+		},
+		{
+			name:  "Extended autolink inside emphasis",
+			input: "*http://localhost:8080*",
+			want: []Token{
+				NewEmphasis([]Token{NewAutolink("http://localhost:8080", "http://localhost:8080")}),
+			},
+		},
+		{
+			name:  "Extended email autolink",
+			input: "mail foo.bar+baz@example.com.",
+			want: []Token{
+				NewText("mail "),
+				NewAutolink("mailto:foo.bar+baz@example.com", "foo.bar+baz@example.com"),
+				NewText("."),
+			},
+		},
+		{
+			name:  "Link text has no extended autolink",
+			input: "[see www.example.com](http://x.org)",
+			want: []Token{
+				NewLink("http://x.org", "", []Token{NewText("see www.example.com")}),
+			},
+		},
+		{
+			name:  "Extended autolink needs a boundary",
+			input: "xwww.example.com",
+			want: []Token{
+				NewText("xwww.example.com"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_WithRawText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "Raw text keeps escapes and entities",
+			input: "\\*a&amp;b\\*",
+			want: []Token{
+				NewText("*a&b*").WithRaw("\\*a&amp;b\\*"),
+			},
+		},
+		{
+			name:  "Raw text inside emphasis",
+			input: "*&lt;tag&gt;*",
+			want: []Token{
+				NewEmphasis([]Token{NewText("<tag>").WithRaw("&lt;tag&gt;")}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NewParser(nil).WithRawText().Parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_WithCommonMark(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "Tildes stay text",
+			input: "~~a~~ *b*",
+			want: []Token{
+				NewText("~~a~~ "),
+				NewEmphasis([]Token{NewText("b")}),
+			},
+		},
+		{
+			name:  "Bare URL stays text",
+			input: "see www.example.com and https://example.com",
+			want: []Token{
+				NewText("see www.example.com and https://example.com"),
+			},
+		},
+		{
+			name:  "Autolink in angle brackets",
+			input: "<https://example.com>",
+			want: []Token{
+				NewAutolink("https://example.com", "https://example.com"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NewParser(nil).WithCommonMark().Parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Text",
+			input: "plain text",
+		},
+		{
+			name:  "Emphasis",
+			input: "*a **b** c* and `code`",
+		},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Parse(tt.input)
+			}
+		})
+	}
+}
+
+func TestImage_Alt(t *testing.T) {
+	t.Parallel()
+
+	image := NewImage("/img.png", "", []Token{
+		NewText("foo "),
+		NewStrong([]Token{NewText("bar")}),
+		NewCodeSpan(" baz"),
+	})
+
+	if got := image.Alt(); got != "foo bar baz" {
+		t.Errorf("Image.Alt() = %q, want %q", got, "foo bar baz")
+	}
+}
+
+func TestParser_ParseReferenceLinks(t *testing.T) {
+	t.Parallel()
+
+	references := make(References)
+	references.Add("foo", NewReference("/url", "title"))
+	references.Add("Bar Baz", NewReference("/bar", ""))
+	references.Add("r", NewReference("/r", ""))
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "Full reference link",
+			input: "[text][foo]",
+			want: []Token{
+				NewLink("/url", "title", []Token{NewText("text")}),
+			},
+		},
+		{
+			name:  "Full reference link label is case-insensitive",
+			input: "[text][FOO]",
+			want: []Token{
+				NewLink("/url", "title", []Token{NewText("text")}),
+			},
+		},
+		{
+			name:  "Collapsed reference link",
+			input: "[foo][]",
+			want: []Token{
+				NewLink("/url", "title", []Token{NewText("foo")}),
+			},
+		},
+		{
+			name:  "Shortcut reference link with collapsed whitespace",
+			input: "[bar\tbaz]",
+			want: []Token{
+				NewLink("/bar", "", []Token{NewText("bar\tbaz")}),
+			},
+		},
+		{
+			name:  "Reference image",
+			input: "![foo]",
+			want: []Token{
+				NewImage("/url", "title", []Token{NewText("foo")}),
+			},
+		},
+		{
+			name:  "Undefined label is text",
+			input: "[text][missing]",
+			want: []Token{
+				NewText("[text][missing]"),
+			},
+		},
+		{
+			name:  "Undefined full reference falls back to the following label",
+			input: "[missing][foo]",
+			want: []Token{
+				NewLink("/url", "title", []Token{NewText("missing")}),
+			},
+		},
+		{
+			name:  "Reference link text has no extended autolink",
+			input: "[a http://b.com c][r]",
+			want: []Token{
+				NewLink("/r", "", []Token{NewText("a http://b.com c")}),
+			},
+		},
+		{
+			name:  "Inline link takes precedence",
+			input: "[foo](/inline)",
+			want: []Token{
+				NewLink("/inline", "", []Token{NewText("foo")}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NewParser(references).Parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseFootnoteReferences(t *testing.T) {
+	t.Parallel()
+
+	footnotes := make(Footnotes)
+	footnotes.Add("1")
+	footnotes.Add("Note")
+
+	references := make(References)
+	references.Add("^link", NewReference("/url", ""))
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "Footnote reference",
+			input: "text[^1].",
+			want: []Token{
+				NewText("text"),
+				NewFootnoteReference("1"),
+				NewText("."),
+			},
+		},
+		{
+			name:  "Footnote label is case-insensitive",
+			input: "[^NOTE]",
+			want: []Token{
+				NewFootnoteReference("NOTE"),
+			},
+		},
+		{
+			name:  "Undefined footnote falls back to a reference link",
+			input: "[^link] [^missing]",
+			want: []Token{
+				NewLink("/url", "", []Token{NewText("^link")}),
+				NewText(" [^missing]"),
+			},
+		},
+		{
+			name:  "Footnote reference inside emphasis",
+			input: "*a[^1]*",
+			want: []Token{
+				NewEmphasis([]Token{NewText("a"), NewFootnoteReference("1")}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NewParser(references).WithFootnotes(footnotes).Parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package inline
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type Reference struct {
+	destination string
+	title       string
+}
+
+func NewReference(destination string, title string) Reference {
+	return Reference{
+		destination: destination,
+		title:       title,
+	}
+}
+func (r Reference) Destination() string {
+	return r.destination
+}
+func (r Reference) Title() string {
+	return r.title
+}
+
+type referenceJSON struct {
+	Destination string `json:"destination"`
+	Title       string `json:"title"`
+}
+
+func (r Reference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(referenceJSON{Destination: r.destination, Title: r.title})
+}
+func (r *Reference) UnmarshalJSON(data []byte) error {
+	var v referenceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*r = NewReference(v.Destination, v.Title)
+
+	return nil
+}
+
+// References maps normalized link labels to their definitions
+type References map[string]Reference
+
+// Add registers the definition unless the label is already defined, as the first definition takes precedence
+func (r References) Add(label string, reference Reference) bool {
+	key := NormalizeLabel(label)
+	if key == "" {
+		return false
+	}
+
+	if _, ok := r[key]; ok {
+		return false
+	}
+
+	r[key] = reference
+
+	return true
+}
+
+func (r References) Lookup(label string) (Reference, bool) {
+	reference, ok := r[NormalizeLabel(label)]
+
+	return reference, ok
+}
+
+// NormalizeLabel strips the outer whitespace, collapses inner whitespace and case-folds the label
+func NormalizeLabel(label string) string {
+	collapsed := strings.Join(strings.Fields(label), " ")
+
+	return strings.ToUpper(strings.ToLower(collapsed))
+}
+
+// parseLinkLabel returns the length of the link label starting at pos including the brackets, or 0
+func parseLinkLabel(input []rune, pos int) int {
+	if pos >= len(input) || input[pos] != '[' {
+		return 0
+	}
+
+	for idx := pos + 1; idx < len(input) && idx-pos <= 1000; idx++ {
+		switch input[idx] {
+		case '\\':
+			idx++
+		case '[':
+			return 0
+		case ']':
+			return idx - pos + 1
+		}
+	}
+
+	return 0
+}
+
+// ParseLinkReferenceDefinition parses a line such as `[foo]: /url "title"`
+func ParseLinkReferenceDefinition(line string) (string, Reference, bool) {
+	input := []rune(line)
+
+	labelLength := parseLinkLabel(input, 0)
+	if labelLength <= 2 || labelLength >= len(input) || input[labelLength] != ':' {
+		return "", Reference{}, false
+	}
+
+	label := string(input[1 : labelLength-1])
+	if strings.TrimSpace(label) == "" {
+		return "", Reference{}, false
+	}
+
+	pos := skipSpaces(input, labelLength+1)
+	if pos >= len(input) {
+		return "", Reference{}, false
+	}
+
+	destination, pos, ok := parseLinkDestination(input, pos)
+	if !ok {
+		return "", Reference{}, false
+	}
+
+	title := ""
+	afterSpaces := skipSpaces(input, pos)
+	if afterSpaces < len(input) {
+		if afterSpaces == pos {
+			return "", Reference{}, false
+		}
+
+		t, end, ok := parseLinkTitle(input, afterSpaces)
+		if !ok || skipSpaces(input, end) != len(input) {
+			return "", Reference{}, false
+		}
+		title = t
+	}
+
+	return label, NewReference(destination, title), true
+}
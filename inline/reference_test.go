@@ -0,0 +1,123 @@
+package inline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Case is folded",
+			input: "Foo",
+			want:  "FOO",
+		},
+		{
+			name:  "Whitespace is collapsed",
+			input: "  foo \t\n bar ",
+			want:  "FOO BAR",
+		},
+		{
+			name:  "Unicode case folding",
+			input: "αγω",
+			want:  "ΑΓΩ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NormalizeLabel(tt.input); got != tt.want {
+				t.Errorf("NormalizeLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferences_Add(t *testing.T) {
+	t.Parallel()
+
+	references := make(References)
+
+	if !references.Add("foo", NewReference("/first", "")) {
+		t.Fatal("References.Add() = false for a new label")
+	}
+
+	if references.Add("FOO", NewReference("/second", "")) {
+		t.Error("References.Add() = true for a duplicated label")
+	}
+
+	if got, ok := references.Lookup("Foo"); !ok || got.Destination() != "/first" {
+		t.Errorf("References.Lookup() = %v, %v / want /first, true", got, ok)
+	}
+}
+
+func TestParseLinkReferenceDefinition(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		label     string
+		reference Reference
+		ok        bool
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  want
+	}{
+		{
+			name:  "Definition",
+			input: "[foo]: /url",
+			want:  want{"foo", NewReference("/url", ""), true},
+		},
+		{
+			name:  "Definition with title",
+			input: `[foo]: /url "title"`,
+			want:  want{"foo", NewReference("/url", "title"), true},
+		},
+		{
+			name:  "Definition with pointy destination and single quoted title",
+			input: "[Foo Bar]: <my url> 'title'",
+			want:  want{"Foo Bar", NewReference("my url", "title"), true},
+		},
+		{
+			name:  "Missing destination",
+			input: "[foo]:",
+			want:  want{"", Reference{}, false},
+		},
+		{
+			name:  "Trailing characters after title",
+			input: `[foo]: /url "title" ok`,
+			want:  want{"", Reference{}, false},
+		},
+		{
+			name:  "Title must be separated by whitespace",
+			input: `[foo]: <bar>"title"`,
+			want:  want{"", Reference{}, false},
+		},
+		{
+			name:  "Blank label",
+			input: "[ ]: /url",
+			want:  want{"", Reference{}, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			label, reference, ok := ParseLinkReferenceDefinition(tt.input)
+			if got := (want{label, reference, ok}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLinkReferenceDefinition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package inline
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TextType     = "Text"
+	EmphasisType = "Emphasis"
+	StrongType   = "Strong"
+	// StrikethroughType strikethrough is text enclosed by `~` or `~~` (GFM)
+	StrikethroughType = "Strikethrough"
+	CodeSpanType      = "CodeSpan"
+	LinkType          = "Link"
+	ImageType         = "Image"
+	AutolinkType      = "Autolink"
+	// SoftBreakType soft break is a line ending inside a paragraph
+	SoftBreakType = "SoftBreak"
+	// HardBreakType hard break is a line ending preceded by two or more spaces or a backslash
+	HardBreakType = "HardBreak"
+	// FootnoteReferenceType footnote reference is `[^label]` whose label has a footnote definition
+	FootnoteReferenceType = "FootnoteReference"
+)
+
+type Type string
+
+type Token interface {
+	Type() Type
+	String() string
+}
+
+// ContainerToken is an inline token that wraps other inline tokens
+type ContainerToken interface {
+	Token
+	Children() []Token
+}
+
+type Text struct {
+	content string
+	raw     string
+}
+
+func NewText(content string) Text {
+	return Text{
+		content: content,
+	}
+}
+func (t Text) Type() Type {
+	return TextType
+}
+func (t Text) Content() string {
+	return t.content
+}
+
+// Raw returns the source text of the content, or the content when the parser does not keep the source text
+func (t Text) Raw() string {
+	if t.raw == "" {
+		return t.content
+	}
+
+	return t.raw
+}
+func (t Text) WithRaw(raw string) Text {
+	t.raw = raw
+	return t
+}
+func (t Text) String() string {
+	if t.raw != "" {
+		return fmt.Sprintf("Type: %s, Content: %s, Raw: %s", TextType, t.content, t.raw)
+	}
+
+	return fmt.Sprintf("Type: %s, Content: %s", TextType, t.content)
+}
+
+type Emphasis struct {
+	children []Token
+}
+
+func NewEmphasis(children []Token) Emphasis {
+	return Emphasis{
+		children: children,
+	}
+}
+func (e Emphasis) Type() Type {
+	return EmphasisType
+}
+func (e Emphasis) Children() []Token {
+	return e.children
+}
+func (e Emphasis) String() string {
+	return fmt.Sprintf("Type: %s, Children: %v", EmphasisType, e.children)
+}
+
+type Strong struct {
+	children []Token
+}
+
+func NewStrong(children []Token) Strong {
+	return Strong{
+		children: children,
+	}
+}
+func (s Strong) Type() Type {
+	return StrongType
+}
+func (s Strong) Children() []Token {
+	return s.children
+}
+func (s Strong) String() string {
+	return fmt.Sprintf("Type: %s, Children: %v", StrongType, s.children)
+}
+
+type Strikethrough struct {
+	children []Token
+}
+
+func NewStrikethrough(children []Token) Strikethrough {
+	return Strikethrough{
+		children: children,
+	}
+}
+func (s Strikethrough) Type() Type {
+	return StrikethroughType
+}
+func (s Strikethrough) Children() []Token {
+	return s.children
+}
+func (s Strikethrough) String() string {
+	return fmt.Sprintf("Type: %s, Children: %v", StrikethroughType, s.children)
+}
+
+type CodeSpan struct {
+	code string
+}
+
+func NewCodeSpan(code string) CodeSpan {
+	return CodeSpan{
+		code: code,
+	}
+}
+func (c CodeSpan) Type() Type {
+	return CodeSpanType
+}
+func (c CodeSpan) Code() string {
+	return c.code
+}
+func (c CodeSpan) String() string {
+	return fmt.Sprintf("Type: %s, Code: %s", CodeSpanType, c.code)
+}
+
+type Link struct {
+	destination string
+	title       string
+	children    []Token
+}
+
+func NewLink(destination string, title string, children []Token) Link {
+	return Link{
+		destination: destination,
+		title:       title,
+		children:    children,
+	}
+}
+func (l Link) Type() Type {
+	return LinkType
+}
+func (l Link) Destination() string {
+	return l.destination
+}
+func (l Link) Title() string {
+	return l.title
+}
+func (l Link) Children() []Token {
+	return l.children
+}
+func (l Link) String() string {
+	return fmt.Sprintf("Type: %s, Destination: %s, Title: %s, Children: %v", LinkType, l.destination, l.title, l.children)
+}
+
+type Image struct {
+	destination string
+	title       string
+	children    []Token
+}
+
+func NewImage(destination string, title string, children []Token) Image {
+	return Image{
+		destination: destination,
+		title:       title,
+		children:    children,
+	}
+}
+func (i Image) Type() Type {
+	return ImageType
+}
+func (i Image) Destination() string {
+	return i.destination
+}
+func (i Image) Title() string {
+	return i.title
+}
+func (i Image) Children() []Token {
+	return i.children
+}
+
+// Alt returns the image description as plain text
+func (i Image) Alt() string {
+	return PlainText(i.children)
+}
+func (i Image) String() string {
+	return fmt.Sprintf("Type: %s, Destination: %s, Title: %s, Children: %v", ImageType, i.destination, i.title, i.children)
+}
+
+type Autolink struct {
+	destination string
+	content     string
+}
+
+func NewAutolink(destination string, content string) Autolink {
+	return Autolink{
+		destination: destination,
+		content:     content,
+	}
+}
+func (a Autolink) Type() Type {
+	return AutolinkType
+}
+func (a Autolink) Destination() string {
+	return a.destination
+}
+func (a Autolink) Content() string {
+	return a.content
+}
+func (a Autolink) String() string {
+	return fmt.Sprintf("Type: %s, Destination: %s, Content: %s", AutolinkType, a.destination, a.content)
+}
+
+type SoftBreak struct{}
+
+func NewSoftBreak() SoftBreak {
+	return SoftBreak{}
+}
+func (s SoftBreak) Type() Type {
+	return SoftBreakType
+}
+func (s SoftBreak) String() string {
+	return fmt.Sprintf("Type: %s", SoftBreakType)
+}
+
+type HardBreak struct{}
+
+func NewHardBreak() HardBreak {
+	return HardBreak{}
+}
+func (h HardBreak) Type() Type {
+	return HardBreakType
+}
+func (h HardBreak) String() string {
+	return fmt.Sprintf("Type: %s", HardBreakType)
+}
+
+type FootnoteReference struct {
+	label string
+}
+
+func NewFootnoteReference(label string) FootnoteReference {
+	return FootnoteReference{
+		label: label,
+	}
+}
+func (f FootnoteReference) Type() Type {
+	return FootnoteReferenceType
+}
+
+// Label returns the label without the leading `^`
+func (f FootnoteReference) Label() string {
+	return f.label
+}
+func (f FootnoteReference) String() string {
+	return fmt.Sprintf("Type: %s, Label: %s", FootnoteReferenceType, f.label)
+}
+
+// PlainText concatenates the textual content of the tokens, dropping all markup
+func PlainText(tokens []Token) string {
+	var builder strings.Builder
+
+	for _, tk := range tokens {
+		switch t := tk.(type) {
+		case Text:
+			builder.WriteString(t.content)
+		case CodeSpan:
+			builder.WriteString(t.code)
+		case Autolink:
+			builder.WriteString(t.content)
+		case SoftBreak, HardBreak:
+			builder.WriteString(" ")
+		case ContainerToken:
+			builder.WriteString(PlainText(t.Children()))
+		}
+	}
+
+	return builder.String()
+}
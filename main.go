@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// exit codes of the command
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: alchemark [-format tokens|json|html] [-highlight] [file ...]
+
+Parses Markdown from the given files, or from stdin when no file (or "-") is given,
+and writes the result to stdout.
+
+Flags:
+`
+
+var errUnknownFormat = errors.New("unknown format")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("alchemark", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	format := flags.String("format", "html", "output format: tokens, json or html")
+	highlight := flags.Bool("highlight", false, "highlight the code blocks of Go, JSON, YAML and shell in the html output")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	write, err := formatter(*format, *highlight)
+	if err != nil {
+		fmt.Fprintf(stderr, "alchemark: %v: %q\n", err, *format)
+		flags.Usage()
+		return exitUsage
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		input, err := readInput(file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "alchemark: %v\n", err)
+			return exitError
+		}
+
+		if err := write(stdout, NewParser(string(input))); err != nil {
+			fmt.Fprintf(stderr, "alchemark: %v\n", err)
+			return exitError
+		}
+	}
+
+	return exitOK
+}
+
+func readInput(file string, stdin io.Reader) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(stdin)
+	}
+
+	return os.ReadFile(file)
+}
+
+// formatter returns the function writing the parse result in the given format
+func formatter(format string, highlight bool) (func(io.Writer, *Parser) error, error) {
+	switch format {
+	case "tokens":
+		return writeTokens, nil
+	case "json":
+		return writeJSON, nil
+	case "html":
+		renderer := NewHTMLRenderer()
+		if highlight {
+			renderer = renderer.WithHighlighter(NewBuiltinHighlighter())
+		}
+
+		return func(w io.Writer, p *Parser) error {
+			return renderer.Render(w, p.ParseToDocument())
+		}, nil
+	default:
+		return nil, errUnknownFormat
+	}
+}
+
+func writeTokens(w io.Writer, p *Parser) error {
+	for _, tk := range p.ParseToBlocks() {
+		if _, err := fmt.Fprintln(w, tk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, p *Parser) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(p.ParseToBlocks())
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "input.md")
+	if err := os.WriteFile(file, []byte("# From file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		args  []string
+		stdin string
+	}
+	type want struct {
+		code   int
+		stdout string
+		stderr string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "HTML from stdin",
+			args: args{args: nil, stdin: "# Heading\n\nParagraph"},
+			want: want{code: exitOK, stdout: "<h1>Heading</h1>\n<p>Paragraph</p>\n"},
+		},
+		{
+			name: "Tokens",
+			args: args{args: []string{"-format", "tokens"}, stdin: "# Heading"},
+			want: want{code: exitOK, stdout: "Type: Heading, Level: 1, InlineString: Heading, ID: heading\n"},
+		},
+		{
+			name: "JSON",
+			args: args{args: []string{"-format", "json"}, stdin: "# Heading"},
+			want: want{code: exitOK, stdout: `"type": "Heading"`},
+		},
+		{
+			name: "Highlighted HTML",
+			args: args{args: []string{"-highlight"}, stdin: "```go\nreturn nil\n```"},
+			want: want{code: exitOK, stdout: `<span class="hl-keyword">return</span> <span class="hl-literal">nil</span>`},
+		},
+		{
+			name: "File and stdin",
+			args: args{args: []string{file, "-"}, stdin: "Paragraph"},
+			want: want{code: exitOK, stdout: "<h1>From file</h1>\n<p>Paragraph</p>\n"},
+		},
+		{
+			name: "Missing file",
+			args: args{args: []string{filepath.Join(dir, "missing.md")}},
+			want: want{code: exitError, stderr: "missing.md"},
+		},
+		{
+			name: "Unknown format",
+			args: args{args: []string{"-format", "xml"}},
+			want: want{code: exitUsage, stderr: `unknown format: "xml"`},
+		},
+		{
+			name: "Unknown flag",
+			args: args{args: []string{"-unknown"}},
+			want: want{code: exitUsage, stderr: "Usage: alchemark"},
+		},
+		{
+			name: "Help",
+			args: args{args: []string{"-h"}},
+			want: want{code: exitOK, stderr: "Usage: alchemark"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args.args, strings.NewReader(tt.args.stdin), &stdout, &stderr)
+
+			if code != tt.want.code {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.want.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.want.stdout) {
+				t.Errorf("run() stdout = %q, want %q", stdout.String(), tt.want.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.want.stderr) {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.want.stderr)
+			}
+		})
+	}
+}
+
+func TestRun_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := "---\ntitle: Title\n---\n| a | b |\n| :- | -: |\n| 1 |\n# Heading\n\n```go\ncode\n```\n[foo]: /url \"title\"\n> quote\n<!--\ncomment\n-->\n3) [x] item\n    nested\nText\n---\n[^1]: note\n    more"
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-format", "json"}, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+
+	got, err := token.UnmarshalBlockTokens(stdout.Bytes())
+	if err != nil {
+		t.Fatalf("token.UnmarshalBlockTokens() error = %v", err)
+	}
+
+	if want := NewParser(input).ParseToBlocks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("token.UnmarshalBlockTokens() = %v, want %v", got, want)
+	}
+}
@@ -5,19 +5,27 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/KasumiMercury/alchemark/inline"
 	"github.com/KasumiMercury/alchemark/token"
 )
 
 type Parser struct {
-	lines []string
+	lines      []string
+	references inline.References
 }
 
 func NewParser(text string) *Parser {
 	return &Parser{
-		lines: strings.Split(text, "\n"),
+		lines:      strings.Split(text, "\n"),
+		references: make(inline.References),
 	}
 }
 
+// References returns the link reference definitions collected by ParseToBlocks
+func (p *Parser) References() inline.References {
+	return p.references
+}
+
 func (p *Parser) ParseToBlocks() []token.BlockToken {
 	if len(p.lines) == 0 {
 		return nil
@@ -60,49 +68,18 @@ func (p *Parser) ParseToBlocks() []token.BlockToken {
 
 	tokens := make([]token.BlockToken, 0, len(p.lines))
 
-	var openingCodeBlockFence *token.CodeBlockFence
-	codeBuffer := make([]string, 0)
-
-	for i, block := range blocks {
-		if block.token.Type() == token.CodeBlockFenceType {
-			if openingCodeBlockFence == nil {
-				openingCodeBlockFence = block.token.(*token.CodeBlockFence)
-				continue
-			}
-
-			fenceToken := block.token.(*token.CodeBlockFence)
-			if fenceToken.InfoString() == "" && fenceToken.FenceChar() == openingCodeBlockFence.FenceChar() {
-				tokens = append(tokens, token.NewCodeBlock(openingCodeBlockFence.InfoString(), codeBuffer))
-				openingCodeBlockFence = nil
-				codeBuffer = codeBuffer[:0]
-				continue
-			}
-		}
-
-		if block.token.Type() == token.IndentedBlockType {
-			self := block.token.(token.IndentedBlock).ConvertBlockToIndentedCodeBlock(blocks[i-1].token.Type())
-			tokens[len(tokens)-1] = self
-		}
-
-		if sht, ok := block.token.(token.SetextHeadingToken); ok {
-			if i == 0 {
-				tokens = append(tokens, sht.ConvertBlockToParagraph())
-				continue
-			} else {
-				target, self := sht.ConvertBlockToSetextHeading(blocks[i-1].token)
-				tokens[len(tokens)-1] = target
-				tokens = append(tokens, self)
-				continue
-			}
-		}
-
-		if openingCodeBlockFence != nil {
-			codeBuffer = append(codeBuffer, p.lines[block.index])
-			continue
-		}
-
-		tokens = append(tokens, block.token)
+	builder := newBlockBuilder(p.references, func(tk token.BlockToken) {
+		tokens = append(tokens, tk)
+	})
+	for _, block := range blocks {
+		builder.add(p.lines[block.index], block.token)
 	}
+	builder.flush()
 
 	return tokens
 }
+
+// ParseToDocument parses the text and groups the blocks into a document tree
+func (p *Parser) ParseToDocument() *token.Document {
+	return BuildTree(p.lines, p.ParseToBlocks(), p.references)
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/KasumiMercury/alchemark/inline"
 	"github.com/KasumiMercury/alchemark/token"
 )
 
@@ -23,7 +24,7 @@ func TestParser_ParseToBlock(t *testing.T) {
 		{
 			input: "# Heading\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 1),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
 				token.NewParagraphBlock("Paragraph", 0),
 			},
 		},
@@ -36,7 +37,7 @@ func TestParser_ParseToBlock(t *testing.T) {
 		{
 			input: "Heading\n=\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 1),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
 				token.NewSetextHeading(),
 				token.NewParagraphBlock("Paragraph", 0),
 			},
@@ -44,7 +45,7 @@ func TestParser_ParseToBlock(t *testing.T) {
 		{
 			input: "Heading\n-\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 2),
+				token.NewHeadingBlock("Heading", 2).WithID("heading"),
 				token.NewSetextHeading(),
 				token.NewParagraphBlock("Paragraph", 0),
 			},
@@ -52,15 +53,14 @@ func TestParser_ParseToBlock(t *testing.T) {
 		{
 			input: "# Heading\n=\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 1),
-				token.NewParagraphBlock("=", 0),
-				token.NewParagraphBlock("Paragraph", 0),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
+				token.NewParagraphBlock("=\nParagraph", 0),
 			},
 		},
 		{
 			input: "# Heading\n---\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 1),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
 				token.NewHorizontal(),
 				token.NewParagraphBlock("Paragraph", 0),
 			},
@@ -68,18 +68,268 @@ func TestParser_ParseToBlock(t *testing.T) {
 		{
 			input: "# Heading\n--\nParagraph",
 			want: []token.BlockToken{
-				token.NewHeadingBlock("Heading", 1),
-				token.NewParagraphBlock("--", 0),
-				token.NewParagraphBlock("Paragraph", 0),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
+				token.NewParagraphBlock("--\nParagraph", 0),
 			},
 		},
 		{
 			input: "-\nParagraph",
 			want: []token.BlockToken{
-				token.NewParagraphBlock("-", 0),
+				token.NewParagraphBlock("-\nParagraph", 0),
+			},
+		},
+		{
+			input: "```\nfirst\n```\n~~~\nsecond\n---\n~~~",
+			want: []token.BlockToken{
+				token.NewCodeBlock("", []string{"first"}),
+				token.NewCodeBlock("", []string{"second", "---"}),
+			},
+		},
+		{
+			input: "````md\n```\ncode\n```\n`````\nParagraph",
+			want: []token.BlockToken{
+				token.NewCodeBlock("md", []string{"```", "code", "```"}),
 				token.NewParagraphBlock("Paragraph", 0),
 			},
 		},
+		{
+			input: "  ```\n    indented\n   less\n  ```",
+			want: []token.BlockToken{
+				token.NewCodeBlock("", []string{"  indented", " less"}),
+			},
+		},
+		{
+			input: "~~~\ncode\n``` \n~~~ go",
+			want: []token.BlockToken{
+				token.NewCodeBlock("", []string{"code", "``` ", "~~~ go"}),
+			},
+		},
+		{
+			input: "```go\ncode\n\n",
+			want: []token.BlockToken{
+				token.NewCodeBlock("go", []string{"code", ""}),
+			},
+		},
+		{
+			input: "aaa\n``` a`b",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("aaa\n``` a`b", 0),
+			},
+		},
+		{
+			input: "    code\nParagraph\n    continued",
+			want: []token.BlockToken{
+				token.NewIndentedCodeBlock(1, []rune("code")),
+				token.NewParagraphBlock("Paragraph\ncontinued", 0),
+			},
+		},
+		{
+			input: "    first\n\n      \n        second\n\n\nParagraph",
+			want: []token.BlockToken{
+				token.NewIndentedCodeBlock(1, []rune("first")).AppendLine("").AppendLine("  ").AppendLine("    second"),
+				token.NewBlank(),
+				token.NewBlank(),
+				token.NewParagraphBlock("Paragraph", 0),
+			},
+		},
+		{
+			input: "- item\n    nested",
+			want: []token.BlockToken{
+				token.NewListItem('-', 0, token.NewParagraphBlock("item", 0)),
+				token.NewIndentedBlock(1, []rune("nested")),
+			},
+		},
+		{
+			input: "first\n  second\nthird",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("first\nsecond\nthird", 0),
+			},
+		},
+		{
+			input: "first\nsecond\n===",
+			want: []token.BlockToken{
+				token.NewHeadingBlock("first\nsecond", 1).WithID("first-second"),
+				token.NewSetextHeading(),
+			},
+		},
+		{
+			input: "> first\n> second\nlazy",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, token.NewParagraphBlock("first\nsecond\nlazy", 0)),
+			},
+		},
+		{
+			input: ">> first\n> lazy",
+			want: []token.BlockToken{
+				token.NewBlockQuote(2, token.NewParagraphBlock("first\nlazy", 0)),
+			},
+		},
+		{
+			input: "> first\n>> nested",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, token.NewParagraphBlock("first", 0)),
+				token.NewBlockQuote(2, token.NewParagraphBlock("nested", 0)),
+			},
+		},
+		{
+			input: "> first\n    lazy\n> # Heading",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, token.NewParagraphBlock("first\nlazy", 0)),
+				token.NewBlockQuote(1, token.NewHeadingBlock("Heading", 1).WithID("heading")),
+			},
+		},
+		{
+			input: "- item\nlazy\n- [x] task\n  continued",
+			want: []token.BlockToken{
+				token.NewListItem('-', 0, token.NewParagraphBlock("item\nlazy", 0)),
+				token.NewListItem('-', 0, token.NewParagraphBlock("task\ncontinued", 0)).WithTask(true),
+			},
+		},
+		{
+			input: "- item\n    nested\nlazy",
+			want: []token.BlockToken{
+				token.NewListItem('-', 0, token.NewParagraphBlock("item", 0)),
+				token.NewIndentedBlock(1, []rune("nested\nlazy")),
+			},
+		},
+		{
+			input: "Intro\n| a |\n| - |",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("Intro", 0),
+				token.NewTable([]string{"a"}, []token.Alignment{token.AlignNone}, nil),
+			},
+		},
+		{
+			input: "Paragraph\n1. first",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("Paragraph", 0),
+				token.NewOrderedListItem(1, '.', 0, token.NewParagraphBlock("first", 0)),
+			},
+		},
+		{
+			input: "Paragraph\n14. not a list",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("Paragraph\n14. not a list", 0),
+			},
+		},
+		{
+			input: "[foo]: /url\n[foo]",
+			want: []token.BlockToken{
+				token.NewLinkReferenceDefinition("foo", "/url", "", []rune("[foo]: /url")),
+				token.NewParagraphBlock("[foo]", 0),
+			},
+		},
+		{
+			input: "<!-- first\n\n# not a heading -->\nText",
+			want: []token.BlockToken{
+				token.NewHTMLBlock(2, []string{"<!-- first", "", "# not a heading -->"}),
+				token.NewParagraphBlock("Text", 0),
+			},
+		},
+		{
+			input: "<pre>\n    code\n</pre>",
+			want: []token.BlockToken{
+				token.NewHTMLBlock(1, []string{"<pre>", "    code", "</pre>"}),
+			},
+		},
+		{
+			input: "<div>\n*text*\n\n*text*",
+			want: []token.BlockToken{
+				token.NewHTMLBlock(6, []string{"<div>", "*text*"}),
+				token.NewBlank(),
+				token.NewParagraphBlock("*text*", 0),
+			},
+		},
+		{
+			input: "Text\n<custom>\n<div>",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("Text\n<custom>", 0),
+				token.NewHTMLBlock(6, []string{"<div>"}),
+			},
+		},
+		{
+			input: "---\ntitle: Title\ntags: [a]\n---\n# Heading",
+			want: []token.BlockToken{
+				token.NewFrontMatter(token.FrontMatterYAML, "title: Title\ntags: [a]"),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
+			},
+		},
+		{
+			input: "+++\ntitle = \"Title\"\n+++",
+			want: []token.BlockToken{
+				token.NewFrontMatter(token.FrontMatterTOML, "title = \"Title\""),
+			},
+		},
+		{
+			input: "---\nTitle\n",
+			want: []token.BlockToken{
+				token.NewHorizontal(),
+				token.NewParagraphBlock("Title", 0),
+				token.NewBlank(),
+			},
+		},
+		{
+			input: "---\ntitle\n\nparagraph",
+			want: []token.BlockToken{
+				token.NewHorizontal(),
+				token.NewParagraphBlock("title", 0),
+				token.NewBlank(),
+				token.NewParagraphBlock("paragraph", 0),
+			},
+		},
+		{
+			input: "+++\ntitle\n\nparagraph",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("+++\ntitle", 0),
+				token.NewBlank(),
+				token.NewParagraphBlock("paragraph", 0),
+			},
+		},
+		{
+			input: "Text\n---\ntitle: Title\n---",
+			want: []token.BlockToken{
+				token.NewHeadingBlock("Text", 2).WithID("text"),
+				token.NewSetextHeading(),
+				token.NewHeadingBlock("title: Title", 2).WithID("title-title"),
+				token.NewSetextHeading(),
+			},
+		},
+		{
+			input: "| a | b |\n| :-- | --: |\n| 1 | 2 | 3 |\n4\n\nText",
+			want: []token.BlockToken{
+				token.NewTable([]string{"a", "b"}, []token.Alignment{token.AlignLeft, token.AlignRight}, [][]string{{"1", "2"}, {"4", ""}}),
+				token.NewBlank(),
+				token.NewParagraphBlock("Text", 0),
+			},
+		},
+		{
+			input: "| a | b |\n| --- |",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("| a | b |\n| --- |", 0),
+			},
+		},
+		{
+			input: "| a |\n| - |\n# Heading",
+			want: []token.BlockToken{
+				token.NewTable([]string{"a"}, []token.Alignment{token.AlignNone}, nil),
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
+			},
+		},
+		{
+			input: "[^1]: Note\nlazy\n\n    More\nText\n[^2]: Second",
+			want: []token.BlockToken{
+				token.NewFootnoteDefinition("1", token.NewParagraphBlock("Note\nlazy", 0)),
+				token.NewBlank(),
+				token.NewIndentedBlock(1, []rune("More\nText")),
+				token.NewFootnoteDefinition("2", token.NewParagraphBlock("Second", 0)),
+			},
+		},
+		{
+			input: "Paragraph\n[foo]: /url",
+			want: []token.BlockToken{
+				token.NewParagraphBlock("Paragraph\n[foo]: /url", 0),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -87,13 +337,94 @@ func TestParser_ParseToBlock(t *testing.T) {
 			t.Parallel()
 
 			p := NewParser(tt.input)
-			if got := p.ParseToBlocks(); !reflect.DeepEqual(got, tt.want) {
+			if got := stripPositions(p.ParseToBlocks()); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Parser.ParseBlocks() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestParser_References(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser("[Foo]: /first 'title'\n\n[foo]: /second\n\n> [bar]: /quoted\n\n[foo] and [bar][]")
+	tokens := p.ParseToBlocks()
+
+	want := inline.References{
+		"FOO": inline.NewReference("/first", "title"),
+		"BAR": inline.NewReference("/quoted", ""),
+	}
+	if got := p.References(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.References() = %v, want %v", got, want)
+	}
+
+	paragraph := tokens[len(tokens)-1].(*token.ParagraphBlock)
+	wantInlines := []inline.Token{
+		inline.NewLink("/first", "title", []inline.Token{inline.NewText("foo")}),
+		inline.NewText(" and "),
+		inline.NewLink("/quoted", "", []inline.Token{inline.NewText("bar")}),
+	}
+	if got := inline.NewParser(p.References()).Parse(paragraph.InlineString()); !reflect.DeepEqual(got, wantInlines) {
+		t.Errorf("inline.Parser.Parse() = %v, want %v", got, wantInlines)
+	}
+}
+
+func TestCodeBlock_Info(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		language   string
+		classes    []string
+		id         string
+		attributes map[string]string
+		lines      []token.LineRange
+	}
+
+	tests := []struct {
+		input string
+		want  want
+	}{
+		{
+			input: "```",
+			want:  want{"", []string{}, "", map[string]string{}, []token.LineRange{}},
+		},
+		{
+			input: "``` ruby startline=3 $%@#$",
+			want:  want{"ruby", []string{}, "", map[string]string{"startline": "3"}, []token.LineRange{}},
+		},
+		{
+			input: "```go title=\"main file.go\" {1,3-5}",
+			want:  want{"go", []string{}, "", map[string]string{"title": "main file.go"}, []token.LineRange{{Start: 1, End: 1}, {Start: 3, End: 5}}},
+		},
+		{
+			input: "```{.python .numberLines #hello startFrom='10'}",
+			want:  want{"python", []string{"numberLines"}, "hello", map[string]string{"startFrom": "10"}, []token.LineRange{}},
+		},
+		{
+			input: "```js {5-3}",
+			want:  want{"js", []string{}, "", map[string]string{}, []token.LineRange{}},
+		},
+		{
+			input: "``` c\\+\\+ title=&quot;a&amp;b&quot;",
+			want:  want{"c++", []string{}, "", map[string]string{"title": "\"a&b\""}, []token.LineRange{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := NewParser(tt.input + "\n```")
+			info := p.ParseToBlocks()[0].(*token.CodeBlock).Info()
+
+			got := want{info.Language(), info.Classes(), info.ID(), info.Attributes(), info.Lines()}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CodeBlock.Info() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkParser_ParseToBlock(b *testing.B) {
 	tests := []struct {
 		name  string
@@ -125,3 +456,43 @@ func BenchmarkParser_ParseToBlock(b *testing.B) {
 		})
 	}
 }
+
+// stripPositions clears the positions of the tokens,
+// so that tests which only check the content can compare them with tokens built by hand
+func stripPositions(blocks []token.BlockToken) []token.BlockToken {
+	stripped := make([]token.BlockToken, 0, len(blocks))
+	for _, tk := range blocks {
+		stripped = append(stripped, token.WithPosition(tk, token.Position{}))
+	}
+
+	return stripped
+}
+
+func TestParser_Positions(t *testing.T) {
+	t.Parallel()
+
+	pos := func(startLine, startColumn, startOffset, endLine, endColumn, endOffset int) token.Position {
+		return token.Position{
+			Start: token.Pos{Offset: startOffset, Line: startLine, Column: startColumn},
+			End:   token.Pos{Offset: endOffset, Line: endLine, Column: endColumn},
+		}
+	}
+
+	p := NewParser("# Heading\n\n```go\ncode\n```\nText\n===")
+	want := []token.Position{
+		pos(1, 1, 0, 1, 10, 9),
+		pos(2, 1, 10, 2, 1, 10),
+		pos(3, 1, 11, 5, 4, 25),
+		pos(6, 1, 26, 7, 4, 34),
+		pos(7, 1, 31, 7, 4, 34),
+	}
+
+	got := make([]token.Position, 0)
+	for _, tk := range p.ParseToBlocks() {
+		got = append(got, tk.Position())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parser.ParseToBlocks() positions = %v, want %v", got, want)
+	}
+}
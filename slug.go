@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// Slugify turns the text into a GitHub compatible anchor.
+// The text is lowercased, every character other than a letter, a number, a mark, `_`, `-` or a space is removed,
+// and each space becomes `-`.
+func Slugify(text string) string {
+	var builder strings.Builder
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			builder.WriteRune('-')
+		case r == '-' || r == '_', unicode.IsLetter(r), unicode.IsNumber(r), unicode.IsMark(r):
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// Slugger generates slugs which are unique in a document,
+// suffixing a slug which is already taken with -1, -2 and so on as GitHub does
+type Slugger struct {
+	occurrences map[string]int
+}
+
+func NewSlugger() *Slugger {
+	return &Slugger{
+		occurrences: make(map[string]int),
+	}
+}
+
+func (s *Slugger) Slug(text string) string {
+	original := Slugify(text)
+
+	slug := original
+	for {
+		if _, taken := s.occurrences[slug]; !taken {
+			break
+		}
+
+		s.occurrences[original]++
+		slug = original + "-" + strconv.Itoa(s.occurrences[original])
+	}
+	s.occurrences[slug] = 0
+
+	return slug
+}
+
+// headingText returns the plain text of the heading, from which its slug is generated
+func headingText(heading *token.HeadingBlock) string {
+	return inline.PlainText(inline.Parse(strings.TrimSpace(heading.InlineString())))
+}
+
+// withHeadingID attaches a slug to the heading, including a heading in a quote or a list item line
+func (s *Slugger) withHeadingID(tk token.BlockToken) token.BlockToken {
+	switch t := tk.(type) {
+	case *token.HeadingBlock:
+		return t.WithID(s.Slug(headingText(t)))
+	case token.BlockQuote:
+		if t.ContentBlock() != nil {
+			return t.WithContentBlock(s.withHeadingID(t.ContentBlock()))
+		}
+	case token.ListItem:
+		if t.ContentBlock() != nil {
+			return t.WithContentBlock(s.withHeadingID(t.ContentBlock()))
+		}
+	}
+
+	return tk
+}
+
+// withTreeHeadingIDs attaches slugs to the headings of the document tree in document order.
+// Headings in quotes, list items and footnote definitions are included,
+// as well as the headings which are only found once the indented lines of a list item are grouped.
+func (s *Slugger) withTreeHeadingIDs(blocks []token.BlockToken) []token.BlockToken {
+	for i, block := range blocks {
+		switch t := block.(type) {
+		case *token.HeadingBlock:
+			blocks[i] = t.WithID(s.Slug(headingText(t)))
+		case token.BlockQuote:
+			blocks[i] = t.WithChildren(s.withTreeHeadingIDs(t.Children()))
+		case token.List:
+			blocks[i] = t.WithChildren(s.withTreeHeadingIDs(t.Children()))
+		case token.ListItem:
+			blocks[i] = t.WithChildren(s.withTreeHeadingIDs(t.Children()))
+		case token.FootnoteDefinition:
+			blocks[i] = t.WithChildren(s.withTreeHeadingIDs(t.Children()))
+		}
+	}
+
+	return blocks
+}
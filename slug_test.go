@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Heading", want: "heading"},
+		{input: "Hello, World!", want: "hello-world"},
+		{input: "foo  bar", want: "foo--bar"},
+		{input: "snake_case and kebab-case", want: "snake_case-and-kebab-case"},
+		{input: "What's `new`?", want: "whats-new"},
+		{input: "日本語 の 見出し", want: "日本語-の-見出し"},
+		{input: "Ünïcödé Çafé", want: "ünïcödé-çafé"},
+		{input: "1.2.3 Release", want: "123-release"},
+		{input: "!!!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Slugify(tt.input); got != tt.want {
+				t.Errorf("Slugify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugger_Slug(t *testing.T) {
+	t.Parallel()
+
+	s := NewSlugger()
+
+	got := make([]string, 0)
+	for _, text := range []string{"Intro", "Intro", "Intro-1", "Intro", "", ""} {
+		got = append(got, s.Slug(text))
+	}
+
+	want := []string{"intro", "intro-1", "intro-1-1", "intro-2", "", "-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Slugger.Slug() = %q, want %q", got, want)
+	}
+}
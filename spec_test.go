@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var specStrict = flag.Bool("spec.strict", false, "fail the test for every CommonMark spec example which does not pass")
+
+// specExample follows the schema of the spec.json published with the CommonMark spec.
+// testdata/spec.json is a subset of 278 of those examples and carries only markdown, html and section,
+// so Example, StartLine and EndLine are zero unless the official file is dropped in.
+type specExample struct {
+	Markdown  string `json:"markdown"`
+	HTML      string `json:"html"`
+	Example   int    `json:"example"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Section   string `json:"section"`
+}
+
+type specSectionResult struct {
+	passed int
+	failed []string
+}
+
+// specExampleID names the example in reports, which is its spec example number when the fixture has one
+// and otherwise its 1-based entry in testdata/spec.json, since the subset does not match the spec numbering
+func specExampleID(example specExample, index int) string {
+	if example.Example != 0 {
+		return fmt.Sprintf("example %d", example.Example)
+	}
+
+	return fmt.Sprintf("entry %d", index+1)
+}
+
+func loadSpecExamples(t *testing.T) []specExample {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "spec.json"))
+	if err != nil {
+		t.Fatalf("failed to read spec examples: %v", err)
+	}
+
+	var examples []specExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		t.Fatalf("failed to decode spec examples: %v", err)
+	}
+
+	return examples
+}
+
+func renderSpecExample(markdown string) (html string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	var builder strings.Builder
+	// the GFM extensions are left out, since the spec examples expect bare URLs and tildes as text
+	if err := NewHTMLRenderer().WithCommonMark().Render(&builder, NewParser(markdown).ParseToDocument()); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// TestCommonMarkSpec reports the conformance per spec section.
+// Run with -v to see the report and with -spec.strict to fail on every example which does not pass.
+func TestCommonMarkSpec(t *testing.T) {
+	examples := loadSpecExamples(t)
+
+	sections := make([]string, 0)
+	results := make(map[string]*specSectionResult)
+
+	for i, example := range examples {
+		id := specExampleID(example, i)
+
+		result, ok := results[example.Section]
+		if !ok {
+			result = &specSectionResult{}
+			results[example.Section] = result
+			sections = append(sections, example.Section)
+		}
+
+		got, err := renderSpecExample(example.Markdown)
+		if err == nil && got == example.HTML {
+			result.passed++
+			continue
+		}
+
+		result.failed = append(result.failed, id)
+
+		if *specStrict {
+			if err != nil {
+				t.Errorf("%s (%s): %v", id, example.Section, err)
+			} else {
+				t.Errorf("%s (%s):\nmarkdown: %q\ngot:      %q\nwant:     %q", id, example.Section, example.Markdown, got, example.HTML)
+			}
+		}
+	}
+
+	totalPassed := 0
+	for _, section := range sections {
+		result := results[section]
+		total := result.passed + len(result.failed)
+		totalPassed += result.passed
+
+		if len(result.failed) == 0 {
+			t.Logf("%-40s %3d/%3d", section, result.passed, total)
+		} else {
+			t.Logf("%-40s %3d/%3d failed: %s", section, result.passed, total, strings.Join(result.failed, ", "))
+		}
+	}
+
+	t.Logf("%-40s %3d/%3d", "Total", totalPassed, len(examples))
+}
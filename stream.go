@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// StreamParser reads Markdown from an io.Reader line by line and emits each block token as soon as it is closed.
+// A document starting with a front matter fence is buffered until the closing fence, or to the end of the input when it is never closed.
+// Its API follows bufio.Scanner:
+//
+//	sp := NewStreamParser(r)
+//	for sp.Scan() {
+//		fmt.Println(sp.Token())
+//	}
+//	if err := sp.Err(); err != nil {
+//		return err
+//	}
+type StreamParser struct {
+	reader     *bufio.Reader
+	references inline.References
+	builder    *blockBuilder
+
+	queue   []token.BlockToken
+	current token.BlockToken
+	done    bool
+	err     error
+}
+
+func NewStreamParser(r io.Reader) *StreamParser {
+	sp := &StreamParser{
+		reader:     bufio.NewReader(r),
+		references: make(inline.References),
+	}
+
+	sp.builder = newBlockBuilder(sp.references, func(tk token.BlockToken) {
+		sp.queue = append(sp.queue, tk)
+	})
+
+	return sp
+}
+
+// Scan advances to the next block token, which is then available through Token.
+// It returns false when the input is exhausted or an error occurs.
+func (sp *StreamParser) Scan() bool {
+	for len(sp.queue) == 0 {
+		if sp.done {
+			sp.current = nil
+			return false
+		}
+
+		sp.readLine()
+	}
+
+	sp.current = sp.queue[0]
+	sp.queue[0] = nil
+	sp.queue = sp.queue[1:]
+
+	return true
+}
+
+func (sp *StreamParser) readLine() {
+	line, err := sp.reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		sp.err = err
+		sp.done = true
+		return
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	sp.builder.add(line, DetectBlockType(line))
+
+	// the text after the last newline is a line as well, which matches strings.Split in NewParser
+	if errors.Is(err, io.EOF) {
+		sp.builder.flush()
+		sp.done = true
+	}
+}
+
+func (sp *StreamParser) Token() token.BlockToken {
+	return sp.current
+}
+
+// Err returns the first non-EOF error that was encountered while reading
+func (sp *StreamParser) Err() error {
+	return sp.err
+}
+
+// References returns the link reference definitions read so far
+func (sp *StreamParser) References() inline.References {
+	return sp.references
+}
+
+// All returns an iterator over the remaining block tokens.
+// Err should be checked after the iteration.
+func (sp *StreamParser) All() iter.Seq[token.BlockToken] {
+	return func(yield func(token.BlockToken) bool) {
+		for sp.Scan() {
+			if !yield(sp.Token()) {
+				return
+			}
+		}
+	}
+}
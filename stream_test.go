@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+func TestStreamParser_Scan(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"",
+		"# Heading\nParagraph",
+		"# Heading\nParagraph\n",
+		"```go\nfunc main() {}\n```\nHeading\n---\n",
+		"[foo]: /url\n- item\n    nested\n\n> quote",
+		"Paragraph\n2. not a list\n    continued",
+		"---\ntitle: Title\n---\n# Heading",
+		"---\nunclosed\n# Heading",
+		"+++\nunclosed\n\nparagraph",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			want := NewParser(input).ParseToBlocks()
+
+			sp := NewStreamParser(strings.NewReader(input))
+			got := make([]token.BlockToken, 0)
+			for sp.Scan() {
+				got = append(got, sp.Token())
+			}
+
+			if err := sp.Err(); err != nil {
+				t.Fatalf("StreamParser.Err() = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("StreamParser tokens = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamParser_EmitsClosedBlocks(t *testing.T) {
+	t.Parallel()
+
+	r, w := io.Pipe()
+	defer r.Close()
+
+	go func() {
+		_, _ = io.WriteString(w, "# Heading\nParagraph\n")
+		// the rest of the input is not written until the first block has been read
+	}()
+
+	sp := NewStreamParser(r)
+	if !sp.Scan() {
+		t.Fatalf("StreamParser.Scan() = false, err = %v", sp.Err())
+	}
+
+	if got, want := stripPositions([]token.BlockToken{sp.Token()}), []token.BlockToken{token.NewHeadingBlock("Heading", 1).WithID("heading")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamParser.Token() = %v, want %v", got, want)
+	}
+
+	w.Close()
+}
+
+func TestStreamParser_Err(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("read failed")
+	sp := NewStreamParser(io.MultiReader(strings.NewReader("# Heading\n"), iotest.ErrReader(readErr)))
+
+	for sp.Scan() {
+	}
+
+	if err := sp.Err(); !errors.Is(err, readErr) {
+		t.Errorf("StreamParser.Err() = %v, want %v", err, readErr)
+	}
+}
+
+func TestStreamParser_All(t *testing.T) {
+	t.Parallel()
+
+	sp := NewStreamParser(strings.NewReader("# Heading\n---\nParagraph"))
+
+	got := make([]token.BlockToken, 0)
+	for tk := range sp.All() {
+		if tk.Type() == token.HorizontalBlockType {
+			break
+		}
+		got = append(got, tk)
+	}
+
+	want := []token.BlockToken{token.NewHeadingBlock("Heading", 1).WithID("heading")}
+	if !reflect.DeepEqual(stripPositions(got), want) {
+		t.Errorf("StreamParser.All() = %v, want %v", got, want)
+	}
+
+	if !sp.Scan() || !reflect.DeepEqual(stripPositions([]token.BlockToken{sp.Token()}), []token.BlockToken{token.NewParagraphBlock("Paragraph", 0)}) {
+		t.Errorf("StreamParser.Scan() after All() = %v", sp.Token())
+	}
+}
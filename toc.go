@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// TOCEntry is a heading in the table of contents together with the headings under it
+type TOCEntry struct {
+	Level    int         `json:"level"`
+	Text     string      `json:"text"`
+	ID       string      `json:"id"`
+	Children []*TOCEntry `json:"children"`
+}
+
+// BuildTOC builds the nested table of contents from the headings of ParseToBlocks output,
+// which include setext headings, or from the children of a document tree,
+// in which case the headings in quotes, lists and footnote definitions are included as well.
+// A heading becomes a child of the closest heading above it with a lower level.
+func BuildTOC(blocks []token.BlockToken) []*TOCEntry {
+	root := &TOCEntry{Children: make([]*TOCEntry, 0)}
+	parents := []*TOCEntry{root}
+
+	for _, heading := range collectHeadings(blocks) {
+		entry := &TOCEntry{
+			Level:    heading.Level(),
+			Text:     headingText(heading),
+			ID:       heading.ID(),
+			Children: make([]*TOCEntry, 0),
+		}
+
+		for len(parents) > 1 && parents[len(parents)-1].Level >= entry.Level {
+			parents = parents[:len(parents)-1]
+		}
+
+		parent := parents[len(parents)-1]
+		parent.Children = append(parent.Children, entry)
+		parents = append(parents, entry)
+	}
+
+	return root.Children
+}
+
+// collectHeadings returns the headings of the blocks and of their children in document order
+func collectHeadings(blocks []token.BlockToken) []*token.HeadingBlock {
+	headings := make([]*token.HeadingBlock, 0)
+
+	for _, block := range blocks {
+		switch t := block.(type) {
+		case *token.HeadingBlock:
+			headings = append(headings, t)
+		case token.BlockQuote:
+			headings = append(headings, collectHeadings(t.Children())...)
+		case token.List:
+			headings = append(headings, collectHeadings(t.Children())...)
+		case token.ListItem:
+			headings = append(headings, collectHeadings(t.Children())...)
+		case token.FootnoteDefinition:
+			headings = append(headings, collectHeadings(t.Children())...)
+		}
+	}
+
+	return headings
+}
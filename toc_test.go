@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTOC(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  []*TOCEntry
+	}{
+		{
+			input: "Paragraph",
+			want:  []*TOCEntry{},
+		},
+		{
+			input: "# Guide\n## Install\n### From *source*\n## Usage\n# FAQ",
+			want: []*TOCEntry{
+				{Level: 1, Text: "Guide", ID: "guide", Children: []*TOCEntry{
+					{Level: 2, Text: "Install", ID: "install", Children: []*TOCEntry{
+						{Level: 3, Text: "From source", ID: "from-source", Children: []*TOCEntry{}},
+					}},
+					{Level: 2, Text: "Usage", ID: "usage", Children: []*TOCEntry{}},
+				}},
+				{Level: 1, Text: "FAQ", ID: "faq", Children: []*TOCEntry{}},
+			},
+		},
+		{
+			input: "Title\n=====\n\nSection\n-------\n\n> # Quoted\n\n### Deep\n## Section",
+			want: []*TOCEntry{
+				{Level: 1, Text: "Title", ID: "title", Children: []*TOCEntry{
+					{Level: 2, Text: "Section", ID: "section", Children: []*TOCEntry{}},
+				}},
+				{Level: 1, Text: "Quoted", ID: "quoted", Children: []*TOCEntry{
+					{Level: 3, Text: "Deep", ID: "deep", Children: []*TOCEntry{}},
+					{Level: 2, Text: "Section", ID: "section-1", Children: []*TOCEntry{}},
+				}},
+			},
+		},
+		{
+			input: "### Third\n# First",
+			want: []*TOCEntry{
+				{Level: 3, Text: "Third", ID: "third", Children: []*TOCEntry{}},
+				{Level: 1, Text: "First", ID: "first", Children: []*TOCEntry{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			if got := BuildTOC(NewParser(tt.input).ParseToBlocks()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildTOC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTOC_DocumentTree(t *testing.T) {
+	t.Parallel()
+
+	doc := NewParser("# Title\n\n- a\n\n  ## Title\n\n> ### Quoted\n\n[^a]\n\n[^a]: Note\n\n    ## Title").ParseToDocument()
+
+	want := []*TOCEntry{
+		{Level: 1, Text: "Title", ID: "title", Children: []*TOCEntry{
+			{Level: 2, Text: "Title", ID: "title-1", Children: []*TOCEntry{
+				{Level: 3, Text: "Quoted", ID: "quoted", Children: []*TOCEntry{}},
+			}},
+			{Level: 2, Text: "Title", ID: "title-2", Children: []*TOCEntry{}},
+		}},
+	}
+	if got := BuildTOC(doc.Children()); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildTOC() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package token
+
+import (
+	"fmt"
+
+	"github.com/KasumiMercury/alchemark/inline"
+)
+
+const (
+	DocumentBlockType = "Document"
+	ListBlockType     = "List"
+)
+
+// ContainerBlock is a block token which holds other blocks
+type ContainerBlock interface {
+	BlockToken
+	Children() []BlockToken
+}
+
+type Document struct {
+	children   []BlockToken
+	references inline.References
+	span
+}
+
+func NewDocument(children []BlockToken, references inline.References) *Document {
+	return &Document{
+		children:   children,
+		references: references,
+	}
+}
+func (d Document) Type() BlockType {
+	return DocumentBlockType
+}
+func (d Document) withPosition(position Position) BlockToken {
+	d.position = position
+	return &d
+}
+func (d Document) Children() []BlockToken {
+	return d.children
+}
+func (d Document) References() inline.References {
+	return d.references
+}
+func (d Document) String() string {
+	return fmt.Sprintf("Type: %s, Children: %v", DocumentBlockType, d.children)
+}
+
+type List struct {
+	marker   rune
+	ordered  bool
+	start    int
+	depth    int
+	tight    bool
+	children []BlockToken
+	span
+}
+
+func NewList(marker rune, depth int, tight bool, items []BlockToken) List {
+	return List{
+		marker:   marker,
+		depth:    depth,
+		tight:    tight,
+		children: items,
+	}
+}
+func NewOrderedList(start int, delimiter rune, depth int, tight bool, items []BlockToken) List {
+	return List{
+		marker:   delimiter,
+		ordered:  true,
+		start:    start,
+		depth:    depth,
+		tight:    tight,
+		children: items,
+	}
+}
+func (l List) Type() BlockType {
+	return ListBlockType
+}
+func (l List) withPosition(position Position) BlockToken {
+	l.position = position
+	return l
+}
+func (l List) Marker() rune {
+	return l.marker
+}
+func (l List) Ordered() bool {
+	return l.ordered
+}
+
+// Start returns the number of the first item of an ordered list
+func (l List) Start() int {
+	return l.start
+}
+func (l List) Depth() int {
+	return l.depth
+}
+
+// Tight reports whether the list items are not separated by blank lines
+func (l List) Tight() bool {
+	return l.tight
+}
+func (l List) Children() []BlockToken {
+	return l.children
+}
+func (l List) WithChildren(children []BlockToken) List {
+	l.children = children
+	return l
+}
+func (l List) String() string {
+	if l.ordered {
+		return fmt.Sprintf("Type: %s, Marker: %c, Start: %d, Depth: %d, Tight: %t, Children: %v", ListBlockType, l.marker, l.start, l.depth, l.tight, l.children)
+	}
+
+	return fmt.Sprintf("Type: %s, Marker: %c, Depth: %d, Tight: %t, Children: %v", ListBlockType, l.marker, l.depth, l.tight, l.children)
+}
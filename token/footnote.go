@@ -0,0 +1,65 @@
+package token
+
+import "fmt"
+
+// FootnoteDefinitionBlockType footnote definition is a line such as `[^1]: text`,
+// whose content continues on the following lines indented by four spaces.
+// Unlike a link reference definition, it may interrupt a paragraph so that definitions can be written on consecutive lines.
+const FootnoteDefinitionBlockType = "FootnoteDefinition"
+
+type FootnoteDefinition struct {
+	label        string
+	contentBlock BlockToken
+	children     []BlockToken
+	span
+}
+
+func NewFootnoteDefinition(label string, contentBlock BlockToken) FootnoteDefinition {
+	return FootnoteDefinition{
+		label:        label,
+		contentBlock: contentBlock,
+	}
+}
+func (f FootnoteDefinition) Type() BlockType {
+	return FootnoteDefinitionBlockType
+}
+func (f FootnoteDefinition) withPosition(position Position) BlockToken {
+	f.position = position
+	return f
+}
+
+// Label returns the label without the leading `^`
+func (f FootnoteDefinition) Label() string {
+	return f.label
+}
+func (f FootnoteDefinition) ContentBlock() BlockToken {
+	return f.contentBlock
+}
+
+// Children returns the blocks of the definition, or its content block when the continuation lines are not grouped yet
+func (f FootnoteDefinition) Children() []BlockToken {
+	if f.children != nil {
+		return f.children
+	}
+
+	if f.contentBlock == nil {
+		return nil
+	}
+
+	return []BlockToken{f.contentBlock}
+}
+func (f FootnoteDefinition) WithContentBlock(contentBlock BlockToken) FootnoteDefinition {
+	f.contentBlock = contentBlock
+	return f
+}
+func (f FootnoteDefinition) WithChildren(children []BlockToken) FootnoteDefinition {
+	f.children = children
+	return f
+}
+func (f FootnoteDefinition) String() string {
+	if f.children != nil {
+		return fmt.Sprintf("Type: %s, Label: %s, Children: %v", FootnoteDefinitionBlockType, f.label, f.children)
+	}
+
+	return fmt.Sprintf("Type: %s, Label: %s, ContentBlock: %s", FootnoteDefinitionBlockType, f.label, f.contentBlock)
+}
@@ -0,0 +1,205 @@
+package token
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+)
+
+// LineRange is an inclusive range of 1-based code lines
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (l LineRange) Contains(line int) bool {
+	return l.Start <= line && line <= l.End
+}
+func (l LineRange) String() string {
+	if l.Start == l.End {
+		return strconv.Itoa(l.Start)
+	}
+
+	return fmt.Sprintf("%d-%d", l.Start, l.End)
+}
+
+// CodeInfo is the info string of a fenced code block split into the language and its attributes.
+// It supports both ```go title="main.go" {1,3-5} and the attribute block form ```{.python .numberLines #id}.
+type CodeInfo struct {
+	language   string
+	classes    []string
+	id         string
+	attributes map[string]string
+	lines      []LineRange
+}
+
+// ParseInfoString splits the info string into its parts.
+// The first word is the language unless it is an attribute block, whose first class is the language instead.
+// Words which are neither a class, an id, a key=value attribute nor line ranges are ignored.
+// The backslash escapes and entity references in the parts are resolved, while CodeBlockFence.InfoString keeps them as written.
+func ParseInfoString(info string) CodeInfo {
+	c := CodeInfo{
+		classes:    make([]string, 0),
+		attributes: make(map[string]string),
+		lines:      make([]LineRange, 0),
+	}
+
+	for i, field := range splitInfoFields(info) {
+		if strings.HasPrefix(field, "{") && strings.HasSuffix(field, "}") {
+			for _, attribute := range splitInfoFields(field[1 : len(field)-1]) {
+				c.addField(attribute)
+			}
+			continue
+		}
+
+		if i == 0 {
+			c.language = inline.Unescape(field)
+			continue
+		}
+
+		c.addField(field)
+	}
+
+	if c.language == "" && len(c.classes) > 0 {
+		c.language = c.classes[0]
+		c.classes = c.classes[1:]
+	}
+
+	return c
+}
+
+func (c *CodeInfo) addField(field string) {
+	switch {
+	case strings.HasPrefix(field, ".") && len(field) > 1:
+		c.classes = append(c.classes, inline.Unescape(field[1:]))
+	case strings.HasPrefix(field, "#") && len(field) > 1:
+		c.id = inline.Unescape(field[1:])
+	case strings.Contains(field, "="):
+		key, value, _ := strings.Cut(field, "=")
+		if key != "" {
+			c.attributes[key] = inline.Unescape(unquote(value))
+		}
+	default:
+		if lines, ok := parseLineRanges(field); ok {
+			c.lines = append(c.lines, lines...)
+		}
+	}
+}
+
+// Language returns the language of the code, or an empty string when it is not given
+func (c CodeInfo) Language() string {
+	return c.language
+}
+
+// Classes returns the classes given in addition to the language
+func (c CodeInfo) Classes() []string {
+	return c.classes
+}
+func (c CodeInfo) ID() string {
+	return c.id
+}
+
+// Attributes returns the key=value attributes with the quotes of the values removed
+func (c CodeInfo) Attributes() map[string]string {
+	return c.attributes
+}
+
+// Title returns the title attribute, which usually holds the file name of the code
+func (c CodeInfo) Title() string {
+	return c.attributes["title"]
+}
+
+// Lines returns the ranges of the lines to highlight
+func (c CodeInfo) Lines() []LineRange {
+	return c.lines
+}
+
+// Highlighted reports whether the 1-based line is in one of the highlighted ranges
+func (c CodeInfo) Highlighted(line int) bool {
+	for _, lineRange := range c.lines {
+		if lineRange.Contains(line) {
+			return true
+		}
+	}
+
+	return false
+}
+func (c CodeInfo) String() string {
+	return fmt.Sprintf("Language: %s, Classes: %q, ID: %s, Attributes: %v, Lines: %v", c.language, c.classes, c.id, c.attributes, c.lines)
+}
+
+// splitInfoFields splits the info string at whitespace which is neither in a quoted value nor in braces
+func splitInfoFields(info string) []string {
+	fields := make([]string, 0)
+
+	var field strings.Builder
+	var quote rune
+	var previous rune
+	braces := 0
+
+	for _, r := range info {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && previous == '=':
+			quote = r
+		case r == '{':
+			braces++
+		case r == '}' && braces > 0:
+			braces--
+		case (r == ' ' || r == '\t') && braces == 0:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			previous = r
+			continue
+		}
+
+		field.WriteRune(r)
+		previous = r
+	}
+
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// parseLineRanges parses comma separated lines and ranges such as `1,3-5`
+func parseLineRanges(field string) ([]LineRange, bool) {
+	lines := make([]LineRange, 0)
+
+	for _, part := range strings.Split(field, ",") {
+		startText, endText, isRange := strings.Cut(part, "-")
+		if !isRange {
+			endText = startText
+		}
+
+		start, err := strconv.Atoi(startText)
+		if err != nil || start < 1 {
+			return nil, false
+		}
+		end, err := strconv.Atoi(endText)
+		if err != nil || end < start {
+			return nil, false
+		}
+
+		lines = append(lines, LineRange{Start: start, End: end})
+	}
+
+	return lines, true
+}
@@ -0,0 +1,935 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/KasumiMercury/alchemark/inline"
+)
+
+// Every block token is encoded as a JSON object whose "type" field holds its BlockType,
+// so that a token stream can be decoded again with UnmarshalBlockToken.
+
+var (
+	ErrUnknownBlockType  = errors.New("unknown block type")
+	ErrBlockTypeMismatch = errors.New("block type mismatch")
+)
+
+// UnmarshalBlockToken decodes a block token of any type, using its "type" field to choose the token type
+func UnmarshalBlockToken(data []byte) (BlockToken, error) {
+	var header struct {
+		Type BlockType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var tk interface {
+		BlockToken
+		json.Unmarshaler
+	}
+
+	switch header.Type {
+	case HeadingBlockType:
+		tk = &HeadingBlock{}
+	case ParagraphBlockType:
+		tk = &ParagraphBlock{}
+	case IndentedBlockType:
+		tk = &IndentedBlock{}
+	case IndentedCodeBlockType:
+		tk = &IndentedCodeBlock{}
+	case CodeBlockType:
+		tk = &CodeBlock{}
+	case CodeBlockFenceType:
+		tk = &CodeBlockFence{}
+	case HyphenBlockType:
+		tk = &HyphenToken{}
+	case EqualBlockType:
+		tk = &EqualToken{}
+	case HorizontalBlockType:
+		tk = &Horizontal{}
+	case SetextBlockType:
+		tk = &SetextHeading{}
+	case BlockQuoteBlockType:
+		tk = &BlockQuote{}
+	case ListItemBlockType:
+		tk = &ListItem{}
+	case LinkReferenceDefinitionBlockType:
+		tk = &LinkReferenceDefinition{}
+	case HTMLBlockType:
+		tk = &HTMLBlock{}
+	case FrontMatterBlockType:
+		tk = &FrontMatter{}
+	case BlankBlockType:
+		tk = &Blank{}
+	case DocumentBlockType:
+		tk = &Document{}
+	case ListBlockType:
+		tk = &List{}
+	case TableBlockType:
+		tk = &Table{}
+	case TableDelimiterRowBlockType:
+		tk = &TableDelimiterRow{}
+	case FootnoteDefinitionBlockType:
+		tk = &FootnoteDefinition{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBlockType, header.Type)
+	}
+
+	if err := tk.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	// the tokens are returned in the same form as their constructors return them
+	switch t := tk.(type) {
+	case *HyphenToken:
+		return *t, nil
+	case *EqualToken:
+		return *t, nil
+	case *Horizontal:
+		return *t, nil
+	case *SetextHeading:
+		return *t, nil
+	case *BlockQuote:
+		return *t, nil
+	case *ListItem:
+		return *t, nil
+	case *LinkReferenceDefinition:
+		return *t, nil
+	case *Blank:
+		return *t, nil
+	case *List:
+		return *t, nil
+	case *TableDelimiterRow:
+		return *t, nil
+	case *FootnoteDefinition:
+		return *t, nil
+	default:
+		return tk, nil
+	}
+}
+
+// UnmarshalBlockTokens decodes a JSON array of block tokens
+func UnmarshalBlockTokens(data []byte) ([]BlockToken, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	return unmarshalBlockTokens(raw)
+}
+
+func unmarshalBlockTokens(raw []json.RawMessage) ([]BlockToken, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	tokens := make([]BlockToken, 0, len(raw))
+	for _, data := range raw {
+		tk, err := UnmarshalBlockToken(data)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tk)
+	}
+
+	return tokens, nil
+}
+
+func unmarshalContentBlock(raw json.RawMessage) (BlockToken, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	return UnmarshalBlockToken(raw)
+}
+
+// unmarshalChildren decodes the children of a quote, list item or footnote definition, which are only present once the tree is built
+func unmarshalChildren(raw *[]json.RawMessage) ([]BlockToken, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	return unmarshalBlockTokens(*raw)
+}
+
+func marshalChildren(children []BlockToken) *[]BlockToken {
+	if children == nil {
+		return nil
+	}
+
+	return &children
+}
+
+func checkType(got BlockType, want BlockType) error {
+	if got != want {
+		return fmt.Errorf("%w: got %q, want %q", ErrBlockTypeMismatch, got, want)
+	}
+
+	return nil
+}
+
+func decodeRune(s string, field string) (rune, error) {
+	runes := []rune(s)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("%s must be a single character: %q", field, s)
+	}
+
+	return runes[0], nil
+}
+
+type headingJSON struct {
+	Type         BlockType `json:"type"`
+	Position     Position  `json:"position"`
+	Level        int       `json:"level"`
+	InlineString string    `json:"inlineString"`
+	ID           string    `json:"id,omitempty"`
+}
+
+func (h HeadingBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(headingJSON{
+		Type:         HeadingBlockType,
+		Position:     h.position,
+		Level:        h.level,
+		InlineString: h.inlineString,
+		ID:           h.id,
+	})
+}
+func (h *HeadingBlock) UnmarshalJSON(data []byte) error {
+	var v headingJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, HeadingBlockType); err != nil {
+		return err
+	}
+	if v.Level < 1 || v.Level > 6 {
+		return fmt.Errorf("heading level must be between 1 and 6: %d", v.Level)
+	}
+
+	*h = HeadingBlock{level: v.Level, inlineString: v.InlineString, id: v.ID, span: span{v.Position}}
+
+	return nil
+}
+
+type paragraphJSON struct {
+	Type         BlockType `json:"type"`
+	Position     Position  `json:"position"`
+	Depth        int       `json:"depth"`
+	InlineString string    `json:"inlineString"`
+}
+
+func (p ParagraphBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(paragraphJSON{
+		Type:         ParagraphBlockType,
+		Position:     p.position,
+		Depth:        p.depth,
+		InlineString: p.inlineString,
+	})
+}
+func (p *ParagraphBlock) UnmarshalJSON(data []byte) error {
+	var v paragraphJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, ParagraphBlockType); err != nil {
+		return err
+	}
+
+	*p = ParagraphBlock{depth: v.Depth, inlineString: v.InlineString, span: span{v.Position}}
+
+	return nil
+}
+
+type indentedJSON struct {
+	Type         BlockType `json:"type"`
+	Position     Position  `json:"position"`
+	Depth        int       `json:"depth"`
+	InlineString string    `json:"inlineString"`
+}
+
+func (i IndentedBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(indentedJSON{
+		Type:         IndentedBlockType,
+		Position:     i.position,
+		Depth:        i.depth,
+		InlineString: string(i.self),
+	})
+}
+func (i *IndentedBlock) UnmarshalJSON(data []byte) error {
+	var v indentedJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, IndentedBlockType); err != nil {
+		return err
+	}
+
+	*i = IndentedBlock{depth: v.Depth, self: []rune(v.InlineString), span: span{v.Position}}
+
+	return nil
+}
+
+type indentedCodeBlockJSON struct {
+	Type      BlockType `json:"type"`
+	Position  Position  `json:"position"`
+	Depth     int       `json:"depth"`
+	CodeLines []string  `json:"codeLines"`
+}
+
+func (i IndentedCodeBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(indentedCodeBlockJSON{
+		Type:      IndentedCodeBlockType,
+		Position:  i.position,
+		Depth:     i.depth,
+		CodeLines: i.codeLines,
+	})
+}
+func (i *IndentedCodeBlock) UnmarshalJSON(data []byte) error {
+	var v indentedCodeBlockJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, IndentedCodeBlockType); err != nil {
+		return err
+	}
+
+	*i = IndentedCodeBlock{depth: v.Depth, codeLines: v.CodeLines, span: span{v.Position}}
+
+	return nil
+}
+
+type codeBlockJSON struct {
+	Type       BlockType `json:"type"`
+	Position   Position  `json:"position"`
+	InfoString string    `json:"infoString"`
+	CodeLines  []string  `json:"codeLines"`
+}
+
+func (c CodeBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(codeBlockJSON{
+		Type:       CodeBlockType,
+		Position:   c.position,
+		InfoString: c.infoString,
+		CodeLines:  c.codeLines,
+	})
+}
+func (c *CodeBlock) UnmarshalJSON(data []byte) error {
+	var v codeBlockJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, CodeBlockType); err != nil {
+		return err
+	}
+
+	*c = CodeBlock{infoString: v.InfoString, codeLines: v.CodeLines, span: span{v.Position}}
+
+	return nil
+}
+
+type codeBlockFenceJSON struct {
+	Type       BlockType `json:"type"`
+	Position   Position  `json:"position"`
+	FenceChar  string    `json:"fenceChar"`
+	Length     int       `json:"length"`
+	Indent     int       `json:"indent"`
+	InfoString string    `json:"infoString"`
+}
+
+func (c CodeBlockFence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(codeBlockFenceJSON{
+		Type:       CodeBlockFenceType,
+		Position:   c.position,
+		FenceChar:  string(c.fenceChar),
+		Length:     c.length,
+		Indent:     c.indent,
+		InfoString: c.infoString,
+	})
+}
+func (c *CodeBlockFence) UnmarshalJSON(data []byte) error {
+	var v codeBlockFenceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, CodeBlockFenceType); err != nil {
+		return err
+	}
+
+	fenceChar, err := decodeRune(v.FenceChar, "fenceChar")
+	if err != nil {
+		return err
+	}
+
+	*c = CodeBlockFence{fenceChar: fenceChar, length: v.Length, indent: v.Indent, infoString: v.InfoString, span: span{v.Position}}
+
+	return nil
+}
+
+type hyphenJSON struct {
+	Type          BlockType `json:"type"`
+	Position      Position  `json:"position"`
+	CanHorizontal bool      `json:"canHorizontal"`
+	Self          string    `json:"self"`
+}
+
+func (h HyphenToken) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hyphenJSON{
+		Type:          HyphenBlockType,
+		Position:      h.position,
+		CanHorizontal: h.canHorizontal,
+		Self:          string(h.self),
+	})
+}
+func (h *HyphenToken) UnmarshalJSON(data []byte) error {
+	var v hyphenJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, HyphenBlockType); err != nil {
+		return err
+	}
+
+	*h = HyphenToken{canHorizontal: v.CanHorizontal, self: []rune(v.Self), span: span{v.Position}}
+
+	return nil
+}
+
+type equalJSON struct {
+	Type     BlockType `json:"type"`
+	Position Position  `json:"position"`
+	Self     string    `json:"self"`
+}
+
+func (e EqualToken) MarshalJSON() ([]byte, error) {
+	return json.Marshal(equalJSON{
+		Type:     EqualBlockType,
+		Position: e.position,
+		Self:     string(e.self),
+	})
+}
+func (e *EqualToken) UnmarshalJSON(data []byte) error {
+	var v equalJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, EqualBlockType); err != nil {
+		return err
+	}
+
+	*e = EqualToken{self: []rune(v.Self), span: span{v.Position}}
+
+	return nil
+}
+
+type htmlBlockJSON struct {
+	Type      BlockType `json:"type"`
+	Position  Position  `json:"position"`
+	Condition int       `json:"condition"`
+	Lines     []string  `json:"lines"`
+}
+
+func (h HTMLBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(htmlBlockJSON{
+		Type:      HTMLBlockType,
+		Position:  h.position,
+		Condition: h.condition,
+		Lines:     h.lines,
+	})
+}
+func (h *HTMLBlock) UnmarshalJSON(data []byte) error {
+	var v htmlBlockJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, HTMLBlockType); err != nil {
+		return err
+	}
+
+	*h = HTMLBlock{condition: v.Condition, lines: v.Lines, span: span{v.Position}}
+
+	return nil
+}
+
+type frontMatterJSON struct {
+	Type     BlockType         `json:"type"`
+	Position Position          `json:"position"`
+	Format   FrontMatterFormat `json:"format"`
+	Content  string            `json:"content"`
+}
+
+func (f FrontMatter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(frontMatterJSON{
+		Type:     FrontMatterBlockType,
+		Position: f.position,
+		Format:   f.format,
+		Content:  f.content,
+	})
+}
+func (f *FrontMatter) UnmarshalJSON(data []byte) error {
+	var v frontMatterJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, FrontMatterBlockType); err != nil {
+		return err
+	}
+
+	*f = FrontMatter{format: v.Format, content: v.Content, span: span{v.Position}}
+
+	return nil
+}
+
+// markJSON is shared by the tokens which have no content
+type markJSON struct {
+	Type     BlockType `json:"type"`
+	Position Position  `json:"position"`
+}
+
+func unmarshalMark(data []byte, blockType BlockType) (span, error) {
+	var v markJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return span{}, err
+	}
+
+	return span{v.Position}, checkType(v.Type, blockType)
+}
+
+func (h Horizontal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(markJSON{Type: HorizontalBlockType, Position: h.position})
+}
+func (h *Horizontal) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalMark(data, HorizontalBlockType)
+	if err != nil {
+		return err
+	}
+
+	*h = Horizontal{span: s}
+
+	return nil
+}
+
+func (s SetextHeading) MarshalJSON() ([]byte, error) {
+	return json.Marshal(markJSON{Type: SetextBlockType, Position: s.position})
+}
+func (s *SetextHeading) UnmarshalJSON(data []byte) error {
+	sp, err := unmarshalMark(data, SetextBlockType)
+	if err != nil {
+		return err
+	}
+
+	*s = SetextHeading{span: sp}
+
+	return nil
+}
+
+func (b Blank) MarshalJSON() ([]byte, error) {
+	return json.Marshal(markJSON{Type: BlankBlockType, Position: b.position})
+}
+func (b *Blank) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalMark(data, BlankBlockType)
+	if err != nil {
+		return err
+	}
+
+	*b = Blank{span: s}
+
+	return nil
+}
+
+type blockQuoteJSON struct {
+	Type         BlockType     `json:"type"`
+	Position     Position      `json:"position"`
+	Depth        int           `json:"depth"`
+	ContentBlock BlockToken    `json:"contentBlock"`
+	Children     *[]BlockToken `json:"children,omitempty"`
+}
+
+type blockQuoteRawJSON struct {
+	Type         BlockType          `json:"type"`
+	Position     Position           `json:"position"`
+	Depth        int                `json:"depth"`
+	ContentBlock json.RawMessage    `json:"contentBlock"`
+	Children     *[]json.RawMessage `json:"children"`
+}
+
+func (b BlockQuote) MarshalJSON() ([]byte, error) {
+	return json.Marshal(blockQuoteJSON{
+		Type:         BlockQuoteBlockType,
+		Position:     b.position,
+		Depth:        b.depth,
+		ContentBlock: b.contentBlock,
+		Children:     marshalChildren(b.children),
+	})
+}
+func (b *BlockQuote) UnmarshalJSON(data []byte) error {
+	var v blockQuoteRawJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, BlockQuoteBlockType); err != nil {
+		return err
+	}
+
+	contentBlock, err := unmarshalContentBlock(v.ContentBlock)
+	if err != nil {
+		return err
+	}
+
+	children, err := unmarshalChildren(v.Children)
+	if err != nil {
+		return err
+	}
+
+	*b = BlockQuote{depth: v.Depth, contentBlock: contentBlock, children: children, span: span{v.Position}}
+
+	return nil
+}
+
+type listItemJSON struct {
+	Type         BlockType     `json:"type"`
+	Position     Position      `json:"position"`
+	Marker       string        `json:"marker"`
+	Ordered      bool          `json:"ordered"`
+	Start        int           `json:"start"`
+	Task         bool          `json:"task"`
+	Checked      bool          `json:"checked"`
+	Depth        int           `json:"depth"`
+	Column       int           `json:"column"`
+	Width        int           `json:"width"`
+	ContentBlock BlockToken    `json:"contentBlock"`
+	Children     *[]BlockToken `json:"children,omitempty"`
+}
+
+type listItemRawJSON struct {
+	Type         BlockType          `json:"type"`
+	Position     Position           `json:"position"`
+	Marker       string             `json:"marker"`
+	Ordered      bool               `json:"ordered"`
+	Start        int                `json:"start"`
+	Task         bool               `json:"task"`
+	Checked      bool               `json:"checked"`
+	Depth        int                `json:"depth"`
+	Column       int                `json:"column"`
+	Width        int                `json:"width"`
+	ContentBlock json.RawMessage    `json:"contentBlock"`
+	Children     *[]json.RawMessage `json:"children"`
+}
+
+func (l ListItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(listItemJSON{
+		Type:         ListItemBlockType,
+		Position:     l.position,
+		Marker:       string(l.marker),
+		Ordered:      l.ordered,
+		Start:        l.start,
+		Task:         l.task,
+		Checked:      l.checked,
+		Depth:        l.depth,
+		Column:       l.column,
+		Width:        l.width,
+		ContentBlock: l.contentBlock,
+		Children:     marshalChildren(l.children),
+	})
+}
+func (l *ListItem) UnmarshalJSON(data []byte) error {
+	var v listItemRawJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, ListItemBlockType); err != nil {
+		return err
+	}
+
+	marker, err := decodeRune(v.Marker, "marker")
+	if err != nil {
+		return err
+	}
+
+	contentBlock, err := unmarshalContentBlock(v.ContentBlock)
+	if err != nil {
+		return err
+	}
+
+	children, err := unmarshalChildren(v.Children)
+	if err != nil {
+		return err
+	}
+
+	*l = ListItem{
+		marker:       marker,
+		ordered:      v.Ordered,
+		start:        v.Start,
+		task:         v.Task,
+		checked:      v.Checked,
+		depth:        v.Depth,
+		column:       v.Column,
+		width:        v.Width,
+		contentBlock: contentBlock,
+		children:     children,
+		span:         span{v.Position},
+	}
+
+	return nil
+}
+
+type linkReferenceDefinitionJSON struct {
+	Type        BlockType `json:"type"`
+	Position    Position  `json:"position"`
+	Label       string    `json:"label"`
+	Destination string    `json:"destination"`
+	Title       string    `json:"title"`
+	Self        string    `json:"self"`
+}
+
+func (l LinkReferenceDefinition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(linkReferenceDefinitionJSON{
+		Type:        LinkReferenceDefinitionBlockType,
+		Position:    l.position,
+		Label:       l.label,
+		Destination: l.destination,
+		Title:       l.title,
+		Self:        string(l.self),
+	})
+}
+func (l *LinkReferenceDefinition) UnmarshalJSON(data []byte) error {
+	var v linkReferenceDefinitionJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, LinkReferenceDefinitionBlockType); err != nil {
+		return err
+	}
+
+	*l = LinkReferenceDefinition{
+		label:       v.Label,
+		destination: v.Destination,
+		title:       v.Title,
+		self:        []rune(v.Self),
+		span:        span{v.Position},
+	}
+
+	return nil
+}
+
+type footnoteDefinitionJSON struct {
+	Type         BlockType     `json:"type"`
+	Position     Position      `json:"position"`
+	Label        string        `json:"label"`
+	ContentBlock BlockToken    `json:"contentBlock"`
+	Children     *[]BlockToken `json:"children,omitempty"`
+}
+
+type footnoteDefinitionRawJSON struct {
+	Type         BlockType          `json:"type"`
+	Position     Position           `json:"position"`
+	Label        string             `json:"label"`
+	ContentBlock json.RawMessage    `json:"contentBlock"`
+	Children     *[]json.RawMessage `json:"children"`
+}
+
+func (f FootnoteDefinition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(footnoteDefinitionJSON{
+		Type:         FootnoteDefinitionBlockType,
+		Position:     f.position,
+		Label:        f.label,
+		ContentBlock: f.contentBlock,
+		Children:     marshalChildren(f.children),
+	})
+}
+func (f *FootnoteDefinition) UnmarshalJSON(data []byte) error {
+	var v footnoteDefinitionRawJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, FootnoteDefinitionBlockType); err != nil {
+		return err
+	}
+
+	contentBlock, err := unmarshalContentBlock(v.ContentBlock)
+	if err != nil {
+		return err
+	}
+
+	children, err := unmarshalChildren(v.Children)
+	if err != nil {
+		return err
+	}
+
+	*f = FootnoteDefinition{
+		label:        v.Label,
+		contentBlock: contentBlock,
+		children:     children,
+		span:         span{v.Position},
+	}
+
+	return nil
+}
+
+type documentJSON struct {
+	Type       BlockType         `json:"type"`
+	Position   Position          `json:"position"`
+	Children   []BlockToken      `json:"children"`
+	References inline.References `json:"references"`
+}
+
+type documentRawJSON struct {
+	Type       BlockType         `json:"type"`
+	Position   Position          `json:"position"`
+	Children   []json.RawMessage `json:"children"`
+	References inline.References `json:"references"`
+}
+
+func (d Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(documentJSON{
+		Type:       DocumentBlockType,
+		Position:   d.position,
+		Children:   d.children,
+		References: d.references,
+	})
+}
+func (d *Document) UnmarshalJSON(data []byte) error {
+	var v documentRawJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, DocumentBlockType); err != nil {
+		return err
+	}
+
+	children, err := unmarshalBlockTokens(v.Children)
+	if err != nil {
+		return err
+	}
+
+	*d = Document{children: children, references: v.References, span: span{v.Position}}
+
+	return nil
+}
+
+type listJSON struct {
+	Type     BlockType    `json:"type"`
+	Position Position     `json:"position"`
+	Marker   string       `json:"marker"`
+	Ordered  bool         `json:"ordered"`
+	Start    int          `json:"start"`
+	Depth    int          `json:"depth"`
+	Tight    bool         `json:"tight"`
+	Children []BlockToken `json:"children"`
+}
+
+type listRawJSON struct {
+	Type     BlockType         `json:"type"`
+	Position Position          `json:"position"`
+	Marker   string            `json:"marker"`
+	Ordered  bool              `json:"ordered"`
+	Start    int               `json:"start"`
+	Depth    int               `json:"depth"`
+	Tight    bool              `json:"tight"`
+	Children []json.RawMessage `json:"children"`
+}
+
+func (l List) MarshalJSON() ([]byte, error) {
+	return json.Marshal(listJSON{
+		Type:     ListBlockType,
+		Position: l.position,
+		Marker:   string(l.marker),
+		Ordered:  l.ordered,
+		Start:    l.start,
+		Depth:    l.depth,
+		Tight:    l.tight,
+		Children: l.children,
+	})
+}
+func (l *List) UnmarshalJSON(data []byte) error {
+	var v listRawJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, ListBlockType); err != nil {
+		return err
+	}
+
+	marker, err := decodeRune(v.Marker, "marker")
+	if err != nil {
+		return err
+	}
+
+	children, err := unmarshalBlockTokens(v.Children)
+	if err != nil {
+		return err
+	}
+
+	*l = List{
+		marker:   marker,
+		ordered:  v.Ordered,
+		start:    v.Start,
+		depth:    v.Depth,
+		tight:    v.Tight,
+		children: children,
+		span:     span{v.Position},
+	}
+
+	return nil
+}
+
+type tableJSON struct {
+	Type       BlockType   `json:"type"`
+	Position   Position    `json:"position"`
+	Header     []string    `json:"header"`
+	Alignments []Alignment `json:"alignments"`
+	Rows       [][]string  `json:"rows"`
+}
+
+func (t Table) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tableJSON{
+		Type:       TableBlockType,
+		Position:   t.position,
+		Header:     t.header,
+		Alignments: t.alignments,
+		Rows:       t.rows,
+	})
+}
+func (t *Table) UnmarshalJSON(data []byte) error {
+	var v tableJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, TableBlockType); err != nil {
+		return err
+	}
+
+	*t = Table{header: v.Header, alignments: v.Alignments, rows: v.Rows, span: span{v.Position}}
+
+	return nil
+}
+
+type tableDelimiterRowJSON struct {
+	Type       BlockType   `json:"type"`
+	Position   Position    `json:"position"`
+	Alignments []Alignment `json:"alignments"`
+	Self       string      `json:"self"`
+}
+
+func (t TableDelimiterRow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tableDelimiterRowJSON{
+		Type:       TableDelimiterRowBlockType,
+		Position:   t.position,
+		Alignments: t.alignments,
+		Self:       string(t.self),
+	})
+}
+func (t *TableDelimiterRow) UnmarshalJSON(data []byte) error {
+	var v tableDelimiterRowJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkType(v.Type, TableDelimiterRowBlockType); err != nil {
+		return err
+	}
+
+	*t = TableDelimiterRow{alignments: v.Alignments, self: []rune(v.Self), span: span{v.Position}}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package token
+
+import "fmt"
+
+// Pos is a location in the source text.
+// Line and Column are 1-based, Column and Offset count bytes.
+type Pos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Position is the span of source text a token was produced from; End is exclusive
+type Position struct {
+	Start Pos `json:"start"`
+	End   Pos `json:"end"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s-%s", p.Start, p.End)
+}
+
+// span is embedded in every block token to record its Position
+type span struct {
+	position Position
+}
+
+func (s span) Position() Position {
+	return s.position
+}
+
+type positionSetter interface {
+	withPosition(position Position) BlockToken
+}
+
+// WithPosition returns a copy of the token which carries the given position
+func WithPosition(tk BlockToken, position Position) BlockToken {
+	if setter, ok := tk.(positionSetter); ok {
+		return setter.withPosition(position)
+	}
+
+	return tk
+}
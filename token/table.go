@@ -0,0 +1,155 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TableBlockType = "Table"
+	// TableDelimiterRowBlockType delimiter row turns the paragraph line above it into a table header
+	TableDelimiterRowBlockType = "TableDelimiterRow"
+)
+
+// Alignment is the alignment of a table column given by its delimiter cell
+type Alignment string
+
+const (
+	AlignNone   Alignment = ""
+	AlignLeft   Alignment = "left"
+	AlignCenter Alignment = "center"
+	AlignRight  Alignment = "right"
+)
+
+type Table struct {
+	header     []string
+	alignments []Alignment
+	rows       [][]string
+	span
+}
+
+func NewTable(header []string, alignments []Alignment, rows [][]string) *Table {
+	return &Table{
+		header:     header,
+		alignments: alignments,
+		rows:       rows,
+	}
+}
+func (t Table) Type() BlockType {
+	return TableBlockType
+}
+func (t Table) withPosition(position Position) BlockToken {
+	t.position = position
+	return &t
+}
+
+// Header returns the inline strings of the header cells
+func (t Table) Header() []string {
+	return t.header
+}
+func (t Table) Alignments() []Alignment {
+	return t.alignments
+}
+
+// Rows returns the inline strings of the body cells, every row having as many cells as the header
+func (t Table) Rows() [][]string {
+	return t.rows
+}
+
+// AddRow returns a copy of the table with the line appended as a body row.
+// Missing cells are filled with empty ones and excess cells are ignored.
+func (t Table) AddRow(line string) *Table {
+	cells := SplitTableRow(line)
+
+	row := make([]string, len(t.alignments))
+	copy(row, cells)
+
+	rows := make([][]string, 0, len(t.rows)+1)
+	rows = append(rows, t.rows...)
+	t.rows = append(rows, row)
+
+	return &t
+}
+func (t Table) String() string {
+	return fmt.Sprintf("Type: %s, Header: %q, Alignments: %q, Rows: %q", TableBlockType, t.header, t.alignments, t.rows)
+}
+
+type TableDelimiterRow struct {
+	alignments []Alignment
+	self       []rune
+	span
+}
+
+func NewTableDelimiterRow(alignments []Alignment, self []rune) TableDelimiterRow {
+	return TableDelimiterRow{
+		alignments: alignments,
+		self:       self,
+	}
+}
+func (t TableDelimiterRow) Type() BlockType {
+	return TableDelimiterRowBlockType
+}
+func (t TableDelimiterRow) withPosition(position Position) BlockToken {
+	t.position = position
+	return t
+}
+func (t TableDelimiterRow) Alignments() []Alignment {
+	return t.alignments
+}
+
+// ConvertBlockToTable turns the last line of the paragraph above the delimiter row into a table header,
+// which requires the header to have the same number of cells as the delimiter row.
+// The preceding lines of the paragraph are returned as a paragraph, or nil when there are none.
+func (t TableDelimiterRow) ConvertBlockToTable(target BlockToken) (BlockToken, BlockToken, bool) {
+	paragraph, ok := target.(*ParagraphBlock)
+	if !ok || paragraph.Depth() != 0 {
+		return nil, nil, false
+	}
+
+	var rest BlockToken
+	headerLine := paragraph.InlineString()
+	if i := strings.LastIndex(headerLine, "\n"); i >= 0 {
+		rest = NewParagraphBlock(headerLine[:i], 0)
+		headerLine = headerLine[i+1:]
+	}
+
+	header := SplitTableRow(headerLine)
+	if len(header) != len(t.alignments) {
+		return nil, nil, false
+	}
+
+	return rest, NewTable(header, t.alignments, nil), true
+}
+func (t TableDelimiterRow) ConvertBlockToParagraph() BlockToken {
+	return NewParagraphBlock(string(t.self), 0)
+}
+func (t TableDelimiterRow) String() string {
+	return fmt.Sprintf("Type: %s, Alignments: %q", TableDelimiterRowBlockType, t.alignments)
+}
+
+// SplitTableRow splits a table row into its trimmed cells.
+// The leading and trailing pipes are optional, and an escaped pipe `\|` is kept in the cell as `|`.
+func SplitTableRow(line string) []string {
+	row := strings.Trim(line, " \t")
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+
+	cells := make([]string, 0)
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.Trim(cell.String(), " \t"))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+
+	return append(cells, strings.Trim(cell.String(), " \t"))
+}
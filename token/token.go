@@ -1,6 +1,13 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+)
 
 const (
 	HeadingBlockType   = "Heading"
@@ -25,6 +32,12 @@ const (
 
 	ListItemBlockType = "ListItem"
 
+	LinkReferenceDefinitionBlockType = "LinkReferenceDefinition"
+
+	FrontMatterBlockType = "FrontMatter"
+
+	HTMLBlockType = "HTMLBlock"
+
 	BlankBlockType = "Blank"
 )
 
@@ -32,6 +45,7 @@ type BlockType string
 
 type BlockToken interface {
 	Type() BlockType
+	Position() Position
 	String() string
 }
 
@@ -44,6 +58,8 @@ type SetextHeadingToken interface {
 type HeadingBlock struct {
 	level        int
 	inlineString string
+	id           string
+	span
 }
 
 func NewHeadingBlock(inlineString string, level int) *HeadingBlock {
@@ -61,19 +77,38 @@ func NewHeadingBlock(inlineString string, level int) *HeadingBlock {
 func (h HeadingBlock) Type() BlockType {
 	return HeadingBlockType
 }
+func (h HeadingBlock) withPosition(position Position) BlockToken {
+	h.position = position
+	return &h
+}
 func (h HeadingBlock) Level() int {
 	return h.level
 }
 func (h HeadingBlock) InlineString() string {
 	return h.inlineString
 }
+
+// Inlines parses the heading text, resolving reference links against the references, e.g. those of the document
+func (h HeadingBlock) Inlines(references inline.References) []inline.Token {
+	return inline.NewParser(references).Parse(h.inlineString)
+}
+
+// ID returns the anchor ID of the heading, which is unique in the document
+func (h HeadingBlock) ID() string {
+	return h.id
+}
+func (h HeadingBlock) WithID(id string) *HeadingBlock {
+	h.id = id
+	return &h
+}
 func (h HeadingBlock) String() string {
-	return fmt.Sprintf("Type: %s, Level: %d, InlineString: %s", HeadingBlockType, h.level, h.inlineString)
+	return fmt.Sprintf("Type: %s, Level: %d, InlineString: %s, ID: %s", HeadingBlockType, h.level, h.inlineString, h.id)
 }
 
 type ParagraphBlock struct {
 	depth        int
 	inlineString string
+	span
 }
 
 func NewParagraphBlock(inlineString string, depth int) *ParagraphBlock {
@@ -85,12 +120,27 @@ func NewParagraphBlock(inlineString string, depth int) *ParagraphBlock {
 func (p ParagraphBlock) Type() BlockType {
 	return ParagraphBlockType
 }
+func (p ParagraphBlock) withPosition(position Position) BlockToken {
+	p.position = position
+	return &p
+}
 func (p ParagraphBlock) Depth() int {
 	return p.depth
 }
 func (p ParagraphBlock) InlineString() string {
 	return p.inlineString
 }
+
+// AppendLine returns a copy of the paragraph continued by the line, whose leading whitespace is stripped
+func (p ParagraphBlock) AppendLine(line string) *ParagraphBlock {
+	p.inlineString += "\n" + strings.TrimLeft(line, " \t")
+	return &p
+}
+
+// Inlines parses the paragraph text, resolving reference links against the references, e.g. those of the document
+func (p ParagraphBlock) Inlines(references inline.References) []inline.Token {
+	return inline.NewParser(references).Parse(p.inlineString)
+}
 func (p ParagraphBlock) String() string {
 	return fmt.Sprintf("Type: %s, Depth: %d, InlineString: %s", ParagraphBlockType, p.depth, p.inlineString)
 }
@@ -98,6 +148,7 @@ func (p ParagraphBlock) String() string {
 type IndentedBlock struct {
 	depth int
 	self  []rune
+	span
 }
 
 func NewIndentedBlock(level int, self []rune) *IndentedBlock {
@@ -109,12 +160,21 @@ func NewIndentedBlock(level int, self []rune) *IndentedBlock {
 func (i IndentedBlock) Type() BlockType {
 	return IndentedBlockType
 }
+func (i IndentedBlock) withPosition(position Position) BlockToken {
+	i.position = position
+	return &i
+}
 func (i IndentedBlock) Depth() int {
 	return i.depth
 }
 func (i IndentedBlock) InlineString() string {
 	return string(i.self)
 }
+
+// Column returns the indentation of the line in columns, including the spaces beyond the depth
+func (i IndentedBlock) Column() int {
+	return i.depth*4 + len(i.self) - len(strings.TrimLeft(string(i.self), " "))
+}
 func (i IndentedBlock) ConvertBlockToIndentedCodeBlock(aboveType BlockType) BlockToken {
 	if aboveType == ParagraphBlockType {
 		return NewParagraphBlock(string(i.self), i.depth)
@@ -127,32 +187,51 @@ func (i IndentedBlock) String() string {
 }
 
 type IndentedCodeBlock struct {
-	depth int
-	self  []rune
+	depth     int
+	codeLines []string
+	span
 }
 
 func NewIndentedCodeBlock(level int, self []rune) *IndentedCodeBlock {
 	return &IndentedCodeBlock{
-		depth: level,
-		self:  self,
+		depth:     level,
+		codeLines: []string{strings.Repeat("    ", level-1) + string(self)},
 	}
 }
 func (i IndentedCodeBlock) Type() BlockType {
 	return IndentedCodeBlockType
 }
+func (i IndentedCodeBlock) withPosition(position Position) BlockToken {
+	i.position = position
+	return &i
+}
+
+// Depth returns the indentation level of the first line
 func (i IndentedCodeBlock) Depth() int {
 	return i.depth
 }
 func (i IndentedCodeBlock) InlineString() string {
-	return string(i.self)
+	return strings.Join(i.codeLines, "\n")
+}
+
+// CodeLines returns the lines of the block without the indentation of the block itself
+func (i IndentedCodeBlock) CodeLines() []string {
+	return i.codeLines
+}
+
+// AppendLine returns a copy of the block continued by the line, which is already stripped of the block indentation
+func (i IndentedCodeBlock) AppendLine(line string) *IndentedCodeBlock {
+	i.codeLines = append(slices.Clip(i.codeLines), line)
+	return &i
 }
 func (i IndentedCodeBlock) String() string {
-	return fmt.Sprintf("Type: %s, Depth: %d, InlineString: %s", IndentedCodeBlockType, i.depth, string(i.self))
+	return fmt.Sprintf("Type: %s, Depth: %d, CodeLines: %v", IndentedCodeBlockType, i.depth, i.codeLines)
 }
 
 type CodeBlock struct {
 	infoString string
 	codeLines  []string
+	span
 }
 
 func NewCodeBlock(infoString string, codeLines []string) *CodeBlock {
@@ -165,9 +244,18 @@ func NewCodeBlock(infoString string, codeLines []string) *CodeBlock {
 func (c CodeBlock) Type() BlockType {
 	return CodeBlockType
 }
+func (c CodeBlock) withPosition(position Position) BlockToken {
+	c.position = position
+	return &c
+}
 func (c CodeBlock) InfoString() string {
 	return c.infoString
 }
+
+// Info returns the info string split into the language and its attributes
+func (c CodeBlock) Info() CodeInfo {
+	return ParseInfoString(c.infoString)
+}
 func (c CodeBlock) CodeLines() []string {
 	return c.codeLines
 }
@@ -177,31 +265,61 @@ func (c CodeBlock) String() string {
 
 type CodeBlockFence struct {
 	fenceChar  rune
+	length     int
+	indent     int
 	infoString string
+	span
 }
 
-func NewCodeBlockFence(fenceChar rune, infoString string) *CodeBlockFence {
+func NewCodeBlockFence(fenceChar rune, length int, infoString string) *CodeBlockFence {
 	return &CodeBlockFence{
 		fenceChar:  fenceChar,
+		length:     length,
 		infoString: infoString,
 	}
 }
 func (c CodeBlockFence) Type() BlockType {
 	return CodeBlockFenceType
 }
+func (c CodeBlockFence) withPosition(position Position) BlockToken {
+	c.position = position
+	return &c
+}
 func (c CodeBlockFence) FenceChar() rune {
 	return c.fenceChar
 }
+
+// Length returns the number of fence characters, which the closing fence must at least have
+func (c CodeBlockFence) Length() int {
+	return c.length
+}
+
+// Indent returns the number of spaces before the fence, which are removed from the code lines
+func (c CodeBlockFence) Indent() int {
+	return c.indent
+}
+
+// WithIndent returns a copy of the fence indented by the given number of spaces
+func (c CodeBlockFence) WithIndent(indent int) *CodeBlockFence {
+	c.indent = indent
+	return &c
+}
 func (c CodeBlockFence) InfoString() string {
 	return c.infoString
 }
+
+// Closes reports whether the fence closes the code block opened by the opening fence
+func (c CodeBlockFence) Closes(opening *CodeBlockFence) bool {
+	return c.infoString == "" && c.fenceChar == opening.fenceChar && c.length >= opening.length
+}
 func (c CodeBlockFence) String() string {
-	return fmt.Sprintf("Type: %s, FenceChar: %c, InfoString: %s", CodeBlockFenceType, c.fenceChar, c.infoString)
+	return fmt.Sprintf("Type: %s, FenceChar: %c, Length: %d, Indent: %d, InfoString: %s", CodeBlockFenceType, c.fenceChar, c.length, c.indent, c.infoString)
 }
 
 type HyphenToken struct {
 	canHorizontal bool
 	self          []rune
+	span
 }
 
 func NewHyphen(canHorizontal bool, self []rune) HyphenToken {
@@ -213,6 +331,10 @@ func NewHyphen(canHorizontal bool, self []rune) HyphenToken {
 func (h HyphenToken) Type() BlockType {
 	return HyphenBlockType
 }
+func (h HyphenToken) withPosition(position Position) BlockToken {
+	h.position = position
+	return h
+}
 func (h HyphenToken) CanHorizontal() bool {
 	return h.canHorizontal
 }
@@ -236,6 +358,7 @@ func (h HyphenToken) String() string {
 
 type EqualToken struct {
 	self []rune
+	span
 }
 
 func NewEqual(self []rune) EqualToken {
@@ -246,6 +369,10 @@ func NewEqual(self []rune) EqualToken {
 func (e EqualToken) Type() BlockType {
 	return EqualBlockType
 }
+func (e EqualToken) withPosition(position Position) BlockToken {
+	e.position = position
+	return e
+}
 func (e EqualToken) ConvertBlockToSetextHeading(target BlockToken) (BlockToken, BlockToken) {
 	if target.Type() == ParagraphBlockType {
 		return NewHeadingBlock(target.(*ParagraphBlock).InlineString(), 1), NewSetextHeading()
@@ -260,7 +387,9 @@ func (e EqualToken) String() string {
 	return fmt.Sprintf("Type: %s", EqualBlockType)
 }
 
-type Horizontal struct{}
+type Horizontal struct {
+	span
+}
 
 func NewHorizontal() Horizontal {
 	return Horizontal{}
@@ -268,11 +397,17 @@ func NewHorizontal() Horizontal {
 func (h Horizontal) Type() BlockType {
 	return HorizontalBlockType
 }
+func (h Horizontal) withPosition(position Position) BlockToken {
+	h.position = position
+	return h
+}
 func (h Horizontal) String() string {
 	return fmt.Sprintf("Type: %s", HorizontalBlockType)
 }
 
-type SetextHeading struct{}
+type SetextHeading struct {
+	span
+}
 
 func NewSetextHeading() SetextHeading {
 	return SetextHeading{}
@@ -280,6 +415,10 @@ func NewSetextHeading() SetextHeading {
 func (s SetextHeading) Type() BlockType {
 	return SetextBlockType
 }
+func (s SetextHeading) withPosition(position Position) BlockToken {
+	s.position = position
+	return s
+}
 func (s SetextHeading) String() string {
 	return fmt.Sprintf("Type: %s", SetextBlockType)
 }
@@ -287,6 +426,8 @@ func (s SetextHeading) String() string {
 type BlockQuote struct {
 	depth        int
 	contentBlock BlockToken
+	children     []BlockToken
+	span
 }
 
 func NewBlockQuote(depth int, contentBlock BlockToken) BlockQuote {
@@ -298,50 +439,307 @@ func NewBlockQuote(depth int, contentBlock BlockToken) BlockQuote {
 func (b BlockQuote) Type() BlockType {
 	return BlockQuoteBlockType
 }
+func (b BlockQuote) withPosition(position Position) BlockToken {
+	b.position = position
+	return b
+}
 func (b BlockQuote) Depth() int {
 	return b.depth
 }
 func (b BlockQuote) ContentBlock() BlockToken {
 	return b.contentBlock
 }
+
+// Children returns the blocks grouped into the quote, or the content block of a single quote line
+func (b BlockQuote) Children() []BlockToken {
+	if b.children != nil {
+		return b.children
+	}
+
+	if b.contentBlock == nil {
+		return nil
+	}
+
+	return []BlockToken{b.contentBlock}
+}
+func (b BlockQuote) WithContentBlock(contentBlock BlockToken) BlockQuote {
+	b.contentBlock = contentBlock
+	return b
+}
+func (b BlockQuote) WithChildren(children []BlockToken) BlockQuote {
+	b.children = children
+	return b
+}
 func (b BlockQuote) String() string {
+	if b.children != nil {
+		return fmt.Sprintf("Type: %s, Depth: %d, Children: %v", BlockQuoteBlockType, b.depth, b.children)
+	}
+
 	return fmt.Sprintf("Type: %s, Depth: %d, ContentBlock: %s", BlockQuoteBlockType, b.depth, b.contentBlock)
 }
 
 type ListItem struct {
-	marker       rune
-	depth        int
+	marker  rune
+	ordered bool
+	start   int
+	task    bool
+	checked bool
+	depth   int
+	// column is the indentation of the marker and width is the columns of the marker and the spaces after it
+	column       int
+	width        int
 	contentBlock BlockToken
+	children     []BlockToken
+	span
 }
 
+// NewListItem creates an item whose marker is followed by one space, at the column of the depth
 func NewListItem(marker rune, depth int, contentBlock BlockToken) ListItem {
 	return ListItem{
 		marker:       marker,
 		depth:        depth,
+		column:       depth * 4,
+		width:        2,
+		contentBlock: contentBlock,
+	}
+}
+
+// NewOrderedListItem creates an item such as `3) item`, whose marker is the delimiter `.` or `)`
+func NewOrderedListItem(start int, delimiter rune, depth int, contentBlock BlockToken) ListItem {
+	return ListItem{
+		marker:       delimiter,
+		ordered:      true,
+		start:        start,
+		depth:        depth,
+		column:       depth * 4,
+		width:        len(strconv.Itoa(start)) + 2,
 		contentBlock: contentBlock,
 	}
 }
 func (l ListItem) Type() BlockType {
 	return ListItemBlockType
 }
+func (l ListItem) withPosition(position Position) BlockToken {
+	l.position = position
+	return l
+}
 func (l ListItem) Marker() rune {
 	return l.marker
 }
+func (l ListItem) Ordered() bool {
+	return l.ordered
+}
+
+// Start returns the number of an ordered list item
+func (l ListItem) Start() int {
+	return l.start
+}
+
+// Task reports whether the item starts with a task list marker `[ ]` or `[x]`
+func (l ListItem) Task() bool {
+	return l.task
+}
+func (l ListItem) Checked() bool {
+	return l.checked
+}
+
+// WithTask returns a copy of the item marked as a task list item
+func (l ListItem) WithTask(checked bool) ListItem {
+	l.task = true
+	l.checked = checked
+	return l
+}
 func (l ListItem) Depth() int {
 	return l.depth
 }
+
+// Column returns the column of the marker, which is the indentation of the item line
+func (l ListItem) Column() int {
+	return l.column
+}
+
+// ContentColumn returns the column at which the content of the item starts.
+// The following lines indented to this column belong to the item.
+func (l ListItem) ContentColumn() int {
+	return l.column + l.width
+}
 func (l ListItem) ContentBlock() BlockToken {
 	return l.contentBlock
 }
+
+// Children returns the blocks grouped into the item, or the content block of a single item line
+func (l ListItem) Children() []BlockToken {
+	if l.children != nil {
+		return l.children
+	}
+
+	if l.contentBlock == nil {
+		return nil
+	}
+
+	return []BlockToken{l.contentBlock}
+}
+func (l ListItem) WithContentBlock(contentBlock BlockToken) ListItem {
+	l.contentBlock = contentBlock
+	return l
+}
+func (l ListItem) WithChildren(children []BlockToken) ListItem {
+	l.children = children
+	return l
+}
 func (l ListItem) String() string {
-	return fmt.Sprintf("Type: %s, Marker: %c, Depth: %d, ContentBlock: %s", ListItemBlockType, l.marker, l.depth, l.contentBlock)
+	marker := string(l.marker)
+	if l.ordered {
+		marker = fmt.Sprintf("%d%c", l.start, l.marker)
+	}
+	if l.task {
+		if l.checked {
+			marker += " [x]"
+		} else {
+			marker += " [ ]"
+		}
+	}
+
+	if l.children != nil {
+		return fmt.Sprintf("Type: %s, Marker: %s, Depth: %d, Children: %v", ListItemBlockType, marker, l.depth, l.children)
+	}
+
+	return fmt.Sprintf("Type: %s, Marker: %s, Depth: %d, ContentBlock: %s", ListItemBlockType, marker, l.depth, l.contentBlock)
+}
+
+// WithColumn returns a copy of the item whose marker is at the column, keeping the width of the marker
+func (l ListItem) WithColumn(column int) ListItem {
+	l.column = column
+	l.depth = column / 4
+	return l
+}
+
+// WithWidth returns a copy of the item whose marker and the spaces after it take the width in columns
+func (l ListItem) WithWidth(width int) ListItem {
+	l.width = width
+	return l
+}
+
+type LinkReferenceDefinition struct {
+	label       string
+	destination string
+	title       string
+	self        []rune
+	span
+}
+
+func NewLinkReferenceDefinition(label string, destination string, title string, self []rune) LinkReferenceDefinition {
+	return LinkReferenceDefinition{
+		label:       label,
+		destination: destination,
+		title:       title,
+		self:        self,
+	}
+}
+func (l LinkReferenceDefinition) Type() BlockType {
+	return LinkReferenceDefinitionBlockType
 }
+func (l LinkReferenceDefinition) withPosition(position Position) BlockToken {
+	l.position = position
+	return l
+}
+func (l LinkReferenceDefinition) Label() string {
+	return l.label
+}
+func (l LinkReferenceDefinition) Destination() string {
+	return l.destination
+}
+func (l LinkReferenceDefinition) Title() string {
+	return l.title
+}
+func (l LinkReferenceDefinition) Reference() inline.Reference {
+	return inline.NewReference(l.destination, l.title)
+}
+
+// ConvertBlockToParagraph is used when the definition would interrupt a paragraph, which is not allowed
+func (l LinkReferenceDefinition) ConvertBlockToParagraph() BlockToken {
+	return NewParagraphBlock(string(l.self), 0)
+}
+func (l LinkReferenceDefinition) String() string {
+	return fmt.Sprintf("Type: %s, Label: %s, Destination: %s, Title: %s", LinkReferenceDefinitionBlockType, l.label, l.destination, l.title)
+}
+
+type HTMLBlock struct {
+	condition int
+	lines     []string
+	span
+}
+
+// NewHTMLBlock creates a block of raw HTML lines, started by one of the seven start conditions of CommonMark
+func NewHTMLBlock(condition int, lines []string) *HTMLBlock {
+	return &HTMLBlock{
+		condition: condition,
+		lines:     lines,
+	}
+}
+func (h HTMLBlock) Type() BlockType {
+	return HTMLBlockType
+}
+func (h HTMLBlock) withPosition(position Position) BlockToken {
+	h.position = position
+	return &h
+}
+
+// Condition returns the start condition (1-7), which determines how the block ends
+func (h HTMLBlock) Condition() int {
+	return h.condition
+}
+func (h HTMLBlock) Lines() []string {
+	return h.lines
+}
+func (h HTMLBlock) String() string {
+	return fmt.Sprintf("Type: %s, Condition: %d, Lines: %q", HTMLBlockType, h.condition, h.lines)
+}
+
+// FrontMatterFormat is the metadata format of a front matter block, given by its fence
+type FrontMatterFormat string
 
-func (l ListItem) Indent(depth int) ListItem {
-	return NewListItem(l.marker, depth, l.contentBlock)
+const (
+	// FrontMatterYAML front matter is fenced by `---`
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	// FrontMatterTOML front matter is fenced by `+++`
+	FrontMatterTOML FrontMatterFormat = "toml"
+)
+
+type FrontMatter struct {
+	format  FrontMatterFormat
+	content string
+	span
 }
 
-type Blank struct{}
+func NewFrontMatter(format FrontMatterFormat, content string) *FrontMatter {
+	return &FrontMatter{
+		format:  format,
+		content: content,
+	}
+}
+func (f FrontMatter) Type() BlockType {
+	return FrontMatterBlockType
+}
+func (f FrontMatter) withPosition(position Position) BlockToken {
+	f.position = position
+	return &f
+}
+func (f FrontMatter) Format() FrontMatterFormat {
+	return f.format
+}
+
+// Content returns the raw lines between the fences, which are not parsed
+func (f FrontMatter) Content() string {
+	return f.content
+}
+func (f FrontMatter) String() string {
+	return fmt.Sprintf("Type: %s, Format: %s, Content: %s", FrontMatterBlockType, f.format, f.content)
+}
+
+type Blank struct {
+	span
+}
 
 func NewBlank() Blank {
 	return Blank{}
@@ -349,6 +747,10 @@ func NewBlank() Blank {
 func (b Blank) Type() BlockType {
 	return BlankBlockType
 }
+func (b Blank) withPosition(position Position) BlockToken {
+	b.position = position
+	return b
+}
 func (b Blank) String() string {
 	return fmt.Sprintf("Type: %s", BlankBlockType)
 }
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+// BuildTree groups the flat block tokens produced by ParseToBlocks from the lines into a document tree.
+// The lines inside block quotes, list items and footnote definitions are parsed again without their markers and indentation,
+// so that their content is resolved the same way as the lines at the top level.
+// The anchor IDs of the headings are assigned again in document order, so that they stay unique
+// together with the headings which are only found inside the containers.
+func BuildTree(lines []string, blocks []token.BlockToken, references inline.References) *token.Document {
+	source := make([]sourceLine, 0, len(lines))
+	offset := 0
+	for i, line := range lines {
+		source = append(source, sourceLine{text: line, position: token.Position{
+			Start: token.Pos{Offset: offset, Line: i + 1, Column: 1},
+			End:   token.Pos{Offset: offset + len(line), Line: i + 1, Column: len(line) + 1},
+		}})
+		offset += len(line) + 1
+	}
+
+	children, _ := treeBuilder{references: references, lines: source}.buildChildren(blocks)
+	children = NewSlugger().withTreeHeadingIDs(children)
+
+	return token.WithPosition(token.NewDocument(children, references), spanOf(children)).(*token.Document)
+}
+
+// sourceLine is a line the blocks of a tree level are parsed from.
+// Inside a container, the text is the line without the markers and indentation of the container.
+type sourceLine struct {
+	text     string
+	position token.Position
+	// lazy is set for a line without the markers of its container, which can only continue a paragraph
+	lazy bool
+}
+
+// treeBuilder groups the blocks parsed from the lines of the document or of a container into children
+type treeBuilder struct {
+	references inline.References
+	lines      []sourceLine
+	quoteDepth int
+}
+
+// spanOf returns the position from the start of the first block to the end of the last block
+func spanOf(blocks []token.BlockToken) token.Position {
+	if len(blocks) == 0 {
+		return token.Position{}
+	}
+
+	return token.Position{
+		Start: blocks[0].Position().Start,
+		End:   blocks[len(blocks)-1].Position().End,
+	}
+}
+
+// linesIn returns the lines covered by the span
+func (t treeBuilder) linesIn(span token.Position) []sourceLine {
+	first := t.lines[0].position.Start.Line
+
+	return t.lines[span.Start.Line-first : span.End.Line-first+1]
+}
+
+// parse runs the lines of a container through a block builder and groups the resulting blocks into its children.
+// A lazy line is added as paragraph text, since it can only continue a paragraph.
+// It reports whether a blank line separates two of the children.
+func (t treeBuilder) parse(lines []sourceLine, quoteDepth int) ([]token.BlockToken, bool) {
+	blocks := make([]token.BlockToken, 0, len(lines))
+	builder := newContainerBuilder(t.references, func(tk token.BlockToken) {
+		blocks = append(blocks, tk)
+	})
+
+	for _, line := range lines {
+		tk := DetectBlockType(line.text)
+		// a paragraph does not keep the indentation of its first line
+		if _, ok := tk.(*token.ParagraphBlock); ok || line.lazy {
+			tk = token.NewParagraphBlock(strings.TrimLeft(line.text, " \t"), 0)
+		}
+
+		builder.addAt(line.text, tk, line.position)
+	}
+	builder.flush()
+
+	return treeBuilder{references: t.references, lines: lines, quoteDepth: quoteDepth}.buildChildren(blocks)
+}
+
+// buildChildren groups the blocks into the children of the document or a container.
+// It reports whether a blank line separates two of the children, which makes a list item loose.
+func (t treeBuilder) buildChildren(blocks []token.BlockToken) ([]token.BlockToken, bool) {
+	children := make([]token.BlockToken, 0, len(blocks))
+	separated := false
+
+	// previousType is the type of the last child and blanks counts the blank lines after it
+	previousType := token.BlockType(token.BlankBlockType)
+	blanks := 0
+
+	appendBlock := func(block token.BlockToken) {
+		count := len(children)
+		children = appendChild(children, block, previousType, blanks)
+		if blanks > 0 && count > 0 && len(children) > count {
+			separated = true
+		}
+
+		previousType = block.Type()
+		blanks = 0
+	}
+
+	for i := 0; i < len(blocks); {
+		var block token.BlockToken
+		next := i + 1
+
+		switch tk := blocks[i].(type) {
+		case token.Blank:
+			blanks++
+			i++
+			continue
+		case token.SetextHeading:
+			i++
+			continue
+		case token.BlockQuote:
+			block, next = t.buildBlockQuote(blocks, i)
+		case token.ListItem:
+			block, next = t.buildList(blocks, i)
+		case token.FootnoteDefinition:
+			block, next = t.buildFootnoteDefinition(blocks, i)
+		case *token.IndentedBlock:
+			// lines indented by less than four columns which did not belong to a list item are ordinary blocks
+			if tk.Depth() == 0 {
+				lines := append([]sourceLine(nil), t.linesIn(tk.Position())...)
+				for j := 1; j < len(lines); j++ {
+					lines[j].lazy = true
+				}
+
+				parsed, _ := t.parse(lines, t.quoteDepth)
+				for _, child := range parsed {
+					appendBlock(child)
+				}
+				i++
+				continue
+			}
+
+			aboveType := previousType
+			if blanks > 0 {
+				aboveType = token.BlankBlockType
+			}
+
+			block = token.WithPosition(tk.ConvertBlockToIndentedCodeBlock(aboveType), tk.Position())
+		default:
+			block = tk
+		}
+
+		appendBlock(block)
+		i = next
+	}
+
+	return children, separated
+}
+
+// appendChild appends the block to the children.
+// Lines of a list item or quote which were indented separately are merged into the paragraph or code block above them.
+func appendChild(children []token.BlockToken, block token.BlockToken, previousType token.BlockType, blanks int) []token.BlockToken {
+	if len(children) == 0 || previousType != block.Type() {
+		return append(children, block)
+	}
+
+	last := children[len(children)-1]
+	position := token.Position{Start: last.Position().Start, End: block.Position().End}
+
+	switch tk := block.(type) {
+	case *token.ParagraphBlock:
+		if blanks == 0 {
+			children[len(children)-1] = token.WithPosition(last.(*token.ParagraphBlock).AppendLine(tk.InlineString()), position)
+			return children
+		}
+	case *token.IndentedCodeBlock:
+		code := last.(*token.IndentedCodeBlock)
+		for range blanks {
+			code = code.AppendLine("")
+		}
+		for _, line := range tk.CodeLines() {
+			code = code.AppendLine(line)
+		}
+
+		children[len(children)-1] = token.WithPosition(code, position)
+		return children
+	}
+
+	return append(children, block)
+}
+
+// buildBlockQuote groups consecutive quote lines into one quote and parses the lines without their first quote marker
+func (t treeBuilder) buildBlockQuote(blocks []token.BlockToken, start int) (token.BlockToken, int) {
+	i := start
+	for i < len(blocks) {
+		if _, ok := blocks[i].(token.BlockQuote); !ok {
+			break
+		}
+		i++
+	}
+
+	position := spanOf(blocks[start:i])
+
+	lines := make([]sourceLine, 0)
+	for _, line := range t.linesIn(position) {
+		if content, ok := quoteLineContent(line.text); ok && !line.lazy {
+			line.text = content
+		} else {
+			line.lazy = true
+		}
+		lines = append(lines, line)
+	}
+
+	children, _ := t.parse(lines, t.quoteDepth+1)
+	quote := token.NewBlockQuote(t.quoteDepth+1, nil).WithChildren(children)
+
+	return token.WithPosition(quote, position), i
+}
+
+// buildList groups sibling list items with the same marker into one list.
+// The lines indented to the content column of an item belong to that item, and an item whose marker is before that column is a sibling.
+// A list is loose when blank lines separate its items or two blocks inside one of its items.
+func (t treeBuilder) buildList(blocks []token.BlockToken, start int) (token.BlockToken, int) {
+	first := blocks[start].(token.ListItem)
+	depth := first.Depth()
+	marker := first.Marker()
+	content := first.ContentColumn()
+
+	items := make([]token.BlockToken, 0)
+	tight := true
+
+	current := first
+	end := first.Position().End
+
+	flush := func() {
+		position := token.Position{Start: current.Position().Start, End: end}
+
+		lines := make([]sourceLine, 0)
+		for j, line := range t.linesIn(position) {
+			switch {
+			case j == 0:
+				line.text = listItemLineContent(line.text, current)
+			case line.lazy:
+			case strings.TrimLeft(line.text, " \t") == "":
+				line.text = ""
+			default:
+				if text, ok := indentedContent(line.text, current.ContentColumn()); ok {
+					line.text = text
+				} else {
+					line.lazy = true
+				}
+			}
+			lines = append(lines, line)
+		}
+
+		children, separated := t.parse(lines, t.quoteDepth)
+		if separated {
+			tight = false
+		}
+
+		items = append(items, token.WithPosition(current.WithChildren(children), position))
+	}
+
+	i := start + 1
+	for i < len(blocks) {
+		if item, ok := blocks[i].(token.ListItem); ok && item.Column() < content {
+			if item.Marker() != marker {
+				break
+			}
+
+			flush()
+			current = item
+			content = item.ContentColumn()
+			end = item.Position().End
+			i++
+			continue
+		}
+
+		if listItemChild(blocks[i], content) {
+			end = blocks[i].Position().End
+			i++
+			continue
+		}
+
+		if blocks[i].Type() != token.BlankBlockType {
+			break
+		}
+
+		next := i
+		for next < len(blocks) && blocks[next].Type() == token.BlankBlockType {
+			next++
+		}
+
+		if next == len(blocks) {
+			break
+		}
+
+		if item, ok := blocks[next].(token.ListItem); ok && item.Column() < content {
+			if item.Marker() != marker {
+				break
+			}
+
+			tight = false
+			i = next
+			continue
+		}
+
+		if !listItemChild(blocks[next], content) {
+			break
+		}
+
+		i = next
+	}
+
+	flush()
+
+	position := token.Position{Start: first.Position().Start, End: end}
+
+	if first.Ordered() {
+		return token.WithPosition(token.NewOrderedList(first.Start(), marker, depth, tight, items), position), i
+	}
+
+	return token.WithPosition(token.NewList(marker, depth, tight, items), position), i
+}
+
+// footnoteContentColumn is the indentation of the lines continuing a footnote definition
+const footnoteContentColumn = 4
+
+// buildFootnoteDefinition parses the lines indented under a footnote definition into its children.
+// Blank lines are kept inside the definition only when more indented lines follow them.
+func (t treeBuilder) buildFootnoteDefinition(blocks []token.BlockToken, start int) (token.BlockToken, int) {
+	definition := blocks[start].(token.FootnoteDefinition)
+	end := definition.Position().End
+
+	i := start + 1
+	for i < len(blocks) {
+		if listItemChild(blocks[i], footnoteContentColumn) {
+			end = blocks[i].Position().End
+			i++
+			continue
+		}
+
+		if blocks[i].Type() != token.BlankBlockType {
+			break
+		}
+
+		next := i
+		for next < len(blocks) && blocks[next].Type() == token.BlankBlockType {
+			next++
+		}
+
+		if next == len(blocks) || !listItemChild(blocks[next], footnoteContentColumn) {
+			break
+		}
+
+		i = next
+	}
+
+	position := token.Position{Start: definition.Position().Start, End: end}
+
+	lines := make([]sourceLine, 0)
+	for j, line := range t.linesIn(position) {
+		switch {
+		case j == 0:
+			line.text = footnoteLineContent(line.text)
+		case line.lazy:
+		case strings.TrimLeft(line.text, " \t") == "":
+			line.text = ""
+		default:
+			if text, ok := indentedContent(line.text, footnoteContentColumn); ok {
+				line.text = text
+			} else {
+				line.lazy = true
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	children, _ := t.parse(lines, t.quoteDepth)
+
+	return token.WithPosition(definition.WithChildren(children), position), i
+}
+
+// listItemChild reports whether the block is indented to the content column of a list item
+func listItemChild(block token.BlockToken, content int) bool {
+	switch tk := block.(type) {
+	case token.ListItem:
+		return tk.Column() >= content
+	case *token.IndentedBlock:
+		return tk.Column() >= content
+	}
+
+	return false
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KasumiMercury/alchemark/inline"
+	"github.com/KasumiMercury/alchemark/token"
+)
+
+func TestParser_ParseToDocument(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []token.BlockToken
+	}{
+		{
+			name:  "Leaf blocks",
+			input: "# Heading\n\nParagraph",
+			want: []token.BlockToken{
+				token.NewHeadingBlock("Heading", 1).WithID("heading"),
+				token.NewParagraphBlock("Paragraph", 0),
+			},
+		},
+		{
+			name:  "Consecutive quote lines become one quote",
+			input: "> first\n>\n> second",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+					token.NewParagraphBlock("first", 0),
+					token.NewParagraphBlock("second", 0),
+				}),
+			},
+		},
+		{
+			name:  "Nested quote",
+			input: "> outer\n> > inner",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+					token.NewParagraphBlock("outer", 0),
+					token.NewBlockQuote(2, nil).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("inner", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Blank line separates quotes",
+			input: "> a\n\n> b",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+					token.NewParagraphBlock("a", 0),
+				}),
+				token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+					token.NewParagraphBlock("b", 0),
+				}),
+			},
+		},
+		{
+			name:  "Sibling items become one tight list",
+			input: "- a\n- b",
+			want: []token.BlockToken{
+				token.NewList('-', 0, true, []token.BlockToken{
+					token.NewListItem('-', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("a", 0),
+					}),
+					token.NewListItem('-', 0, token.NewParagraphBlock("b", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("b", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Blank line between items makes the list loose",
+			input: "- a\n\n- b",
+			want: []token.BlockToken{
+				token.NewList('-', 0, false, []token.BlockToken{
+					token.NewListItem('-', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("a", 0),
+					}),
+					token.NewListItem('-', 0, token.NewParagraphBlock("b", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("b", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Changing the marker starts a new list",
+			input: "- a\n+ b",
+			want: []token.BlockToken{
+				token.NewList('-', 0, true, []token.BlockToken{
+					token.NewListItem('-', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("a", 0),
+					}),
+				}),
+				token.NewList('+', 0, true, []token.BlockToken{
+					token.NewListItem('+', 0, token.NewParagraphBlock("b", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("b", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Nested list and indented paragraph",
+			input: "- a\n    - b\n\n    more a\n- c",
+			want: []token.BlockToken{
+				token.NewList('-', 0, false, []token.BlockToken{
+					token.NewListItem('-', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("a", 0),
+						token.NewList('-', 0, true, []token.BlockToken{
+							token.NewListItem('-', 0, token.NewParagraphBlock("b", 0)).WithColumn(2).WithChildren([]token.BlockToken{
+								token.NewParagraphBlock("b", 0),
+							}),
+						}),
+						token.NewParagraphBlock("more a", 0),
+					}),
+					token.NewListItem('-', 0, token.NewParagraphBlock("c", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("c", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Ordered list keeps the start number of the first item",
+			input: "2. a\n3. b",
+			want: []token.BlockToken{
+				token.NewOrderedList(2, '.', 0, true, []token.BlockToken{
+					token.NewOrderedListItem(2, '.', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("a", 0),
+					}),
+					token.NewOrderedListItem(3, '.', 0, token.NewParagraphBlock("b", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("b", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Paragraph continued inside list item",
+			input: "- item\n    continued\nlazy",
+			want: []token.BlockToken{
+				token.NewList('-', 0, true, []token.BlockToken{
+					token.NewListItem('-', 0, token.NewParagraphBlock("item", 0)).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("item\ncontinued\nlazy", 0),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "List inside quote",
+			input: "> - a\n> - b",
+			want: []token.BlockToken{
+				token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+					token.NewList('-', 0, true, []token.BlockToken{
+						token.NewListItem('-', 0, token.NewParagraphBlock("a", 0)).WithChildren([]token.BlockToken{
+							token.NewParagraphBlock("a", 0),
+						}),
+						token.NewListItem('-', 0, token.NewParagraphBlock("b", 0)).WithChildren([]token.BlockToken{
+							token.NewParagraphBlock("b", 0),
+						}),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "Indented code block outside list",
+			input: "    code",
+			want: []token.BlockToken{
+				token.NewIndentedCodeBlock(1, []rune("code")),
+			},
+		},
+		{
+			name:  "Indented lines become children of footnote definition",
+			input: "[^1]: Note\n    continued\n\n        code\n\n    > quote\n\nAfter",
+			want: []token.BlockToken{
+				token.NewFootnoteDefinition("1", token.NewParagraphBlock("Note", 0)).WithChildren([]token.BlockToken{
+					token.NewParagraphBlock("Note\ncontinued", 0),
+					token.NewIndentedCodeBlock(1, []rune("code")),
+					token.NewBlockQuote(1, nil).WithChildren([]token.BlockToken{
+						token.NewParagraphBlock("quote", 0),
+					}),
+				}),
+				token.NewParagraphBlock("After", 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := NewParser(tt.input)
+			want := token.NewDocument(tt.want, inline.References{})
+			doc := p.ParseToDocument()
+			if got := token.NewDocument(stripTreePositions(doc.Children()), doc.References()); !reflect.DeepEqual(got, want) {
+				t.Errorf("Parser.ParseToDocument() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// stripTreePositions clears the positions of the tokens and all of their descendants
+func stripTreePositions(blocks []token.BlockToken) []token.BlockToken {
+	stripped := make([]token.BlockToken, 0, len(blocks))
+	for _, tk := range blocks {
+		switch t := tk.(type) {
+		case token.BlockQuote:
+			tk = t.WithChildren(stripTreePositions(t.Children()))
+		case token.ListItem:
+			tk = t.WithChildren(stripTreePositions(t.Children()))
+		case token.FootnoteDefinition:
+			tk = t.WithChildren(stripTreePositions(t.Children()))
+		case token.List:
+			tk = t.WithChildren(stripTreePositions(t.Children()))
+		}
+
+		stripped = append(stripped, token.WithPosition(tk, token.Position{}))
+	}
+
+	return stripped
+}
+
+func TestParser_ParseToDocumentPositions(t *testing.T) {
+	t.Parallel()
+
+	doc := NewParser("- a\n- b\n\n> q\n>\n> r").ParseToDocument()
+
+	list := doc.Children()[0].(token.List)
+	quote := doc.Children()[1].(token.BlockQuote)
+
+	tests := []struct {
+		name string
+		got  token.Position
+		want string
+	}{
+		{name: "Document", got: doc.Position(), want: "1:1-6:4"},
+		{name: "List", got: list.Position(), want: "1:1-2:4"},
+		{name: "Second item", got: list.Children()[1].Position(), want: "2:1-2:4"},
+		{name: "Quote", got: quote.Position(), want: "4:1-6:4"},
+		{name: "Quoted paragraph", got: quote.Children()[1].Position(), want: "6:1-6:4"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("%s position = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParser_ParseToDocumentInlines(t *testing.T) {
+	t.Parallel()
+
+	doc := NewParser("# [foo]\n\n[foo]\n\n[foo]: /url").ParseToDocument()
+	heading := doc.Children()[0].(*token.HeadingBlock)
+	paragraph := doc.Children()[1].(*token.ParagraphBlock)
+
+	tests := []struct {
+		name string
+		got  []inline.Token
+	}{
+		{name: "Heading", got: heading.Inlines(doc.References())},
+		{name: "Paragraph", got: paragraph.Inlines(doc.References())},
+	}
+
+	for _, tt := range tests {
+		if len(tt.got) != 1 || tt.got[0].Type() != inline.LinkType {
+			t.Errorf("%s inlines = %v, want a reference link", tt.name, tt.got)
+		}
+	}
+}
+
+func TestDocument_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	doc := NewParser("[foo]: /url\n\n- a\n\n- b\n  1. nested\n> quote\n>\n> [foo][^1]\n\n[^1]: note\n\n    more").ParseToDocument()
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	got, err := token.UnmarshalBlockToken(data)
+	if err != nil {
+		t.Fatalf("token.UnmarshalBlockToken() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, token.BlockToken(doc)) {
+		t.Errorf("token.UnmarshalBlockToken() = %v, want %v", got, doc)
+	}
+}
+
+func TestUnmarshalBlockToken_Error(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{name: "Unknown type", input: `{"type": "Unknown"}`, want: token.ErrUnknownBlockType},
+		{name: "Unknown nested type", input: `{"type": "BlockQuote", "contentBlock": {"type": "Unknown"}}`, want: token.ErrUnknownBlockType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := token.UnmarshalBlockToken([]byte(tt.input)); !errors.Is(err, tt.want) {
+				t.Errorf("token.UnmarshalBlockToken() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}